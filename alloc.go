@@ -0,0 +1,98 @@
+package gotiny
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Allocator carves values of pointers and elements of slices created by decoder out of large blocks,
+// so decoding of many small values makes few allocations. Blocks are arrays of decoded types,
+// so garbage collector sees pointers in them, block is kept alive while any value carved from it is used.
+// Allocator is not safe for concurrent use, it's set to one decoder, see Decoder.SetAllocator.
+type Allocator struct {
+	blockSize int
+	types     map[reflect.Type]*allocBlocks
+}
+
+// allocBlocks are blocks of one type, blocks after cur are reused after Reset
+type allocBlocks struct {
+	arr    reflect.Type // type of block, array of values
+	size   uintptr      // size of value
+	blocks []unsafe.Pointer
+	cur    int // index of current block, -1 before first allocation
+	used   int // number of values used in current block
+}
+
+// NewAllocator creates allocator of blocks of about blockSize bytes, 64KB when blockSize isn't positive.
+// Slices larger than block are allocated separately.
+func NewAllocator(blockSize int) *Allocator {
+	if blockSize <= 0 {
+		blockSize = 1 << 16
+	}
+	return &Allocator{blockSize: blockSize, types: map[reflect.Type]*allocBlocks{}}
+}
+
+// Reset makes allocator reuse its blocks, they are zeroed.
+// Values decoded before reset must not be used after it, they are overwritten by next decoding.
+func (a *Allocator) Reset() {
+	for _, b := range a.types {
+		for i := 0; i <= b.cur; i++ {
+			reflect.NewAt(b.arr, b.blocks[i]).Elem().SetZero()
+		}
+		b.cur, b.used = -1, 0
+	}
+}
+
+// zeroSized is memory of all zero size values and empty slices made by allocators
+var zeroSized struct{}
+
+// alloc returns pointer to n zero values of type rt following each other
+func (a *Allocator) alloc(rt reflect.Type, n int) unsafe.Pointer {
+	if rt.Size() == 0 || n == 0 {
+		return unsafe.Pointer(&zeroSized)
+	}
+	b := a.types[rt]
+	if b == nil {
+		per := a.blockSize / int(rt.Size())
+		if per < 1 {
+			per = 1
+		}
+		b = &allocBlocks{arr: reflect.ArrayOf(per, rt), size: rt.Size(), cur: -1}
+		a.types[rt] = b
+	}
+	if n > b.arr.Len() {
+		return reflect.MakeSlice(reflect.SliceOf(rt), n, n).UnsafePointer()
+	}
+	if b.cur < 0 || b.used+n > b.arr.Len() {
+		b.cur++
+		if b.cur == len(b.blocks) {
+			b.blocks = append(b.blocks, reflect.New(b.arr).UnsafePointer())
+		}
+		b.used = 0
+	}
+	p := unsafe.Add(b.blocks[b.cur], uintptr(b.used)*b.size)
+	b.used += n
+	return p
+}
+
+// newValue returns pointer to new zero value of type rt
+func (d *Decoder) newValue(rt reflect.Type) unsafe.Pointer {
+	if d.allocator != nil {
+		return d.allocator.alloc(rt, 1)
+	}
+	return reflect.New(rt).UnsafePointer()
+}
+
+// sliceHeader is memory of slice of any type, data is pointer so garbage collector keeps array alive
+type sliceHeader struct {
+	data     unsafe.Pointer
+	len, cap int
+}
+
+// makeSlice returns pointer to array of new slice of type rt of length and capacity l
+func (d *Decoder) makeSlice(rt reflect.Type, l int) unsafe.Pointer {
+	if d.allocator != nil {
+		return d.allocator.alloc(rt.Elem(), l)
+	}
+	return reflect.MakeSlice(rt, l, l).UnsafePointer()
+}
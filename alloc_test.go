@@ -0,0 +1,110 @@
+package gotiny_test
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type allocT struct {
+	ID     *int64
+	Name   *string
+	Tags   []string
+	Values []float64 `gotiny:"packed"`
+	Next   *allocT
+	Empty  []struct{}
+}
+
+func allocRecords(n int) []allocT {
+	records := make([]allocT, n)
+	for i := range records {
+		id, name := int64(i), "record "+strconv.Itoa(i)
+		records[i] = allocT{ID: &id, Name: &name, Tags: []string{"a", name}, Values: []float64{float64(i)}, Empty: make([]struct{}, i%3)}
+		if i%2 == 0 {
+			records[i].Next = &allocT{Name: &name}
+		}
+	}
+	return records
+}
+
+func TestAllocator(t *testing.T) {
+	src := allocRecords(1000)
+	data := gotiny.Marshal(&src)
+	d := gotiny.NewDecoderWithPtr(&src)
+	allocator := gotiny.NewAllocator(1 << 12)
+	d.SetAllocator(allocator)
+
+	var got []allocT
+	d.Decode(data, &got)
+	runtime.GC() // blocks keep values they contain alive
+	Assert(t, data, src, got)
+
+	// slices from blocks have no spare capacity, appending doesn't overwrite neighbours
+	tags := append(got[0].Tags, "c")
+	if got[1].Tags[0] != "a" || tags[2] != "c" {
+		t.Errorf("unexpected tags %v %v", got[1].Tags, tags)
+	}
+
+	// strings aren't allocated by allocator, they alias data in both decoders
+	plain := gotiny.NewDecoderWithPtr(&src)
+	plain.SetZeroCopyStrings(true)
+	d.SetZeroCopyStrings(true)
+	decode := func(d *gotiny.Decoder) func() {
+		return func() {
+			var got []allocT
+			d.Decode(data, &got)
+		}
+	}
+	withAllocator := testing.AllocsPerRun(10, func() {
+		allocator.Reset()
+		decode(d)()
+	})
+	without := testing.AllocsPerRun(10, decode(plain))
+	if withAllocator*100 > without {
+		t.Errorf("expected much less allocations with allocator, got %v and %v without", withAllocator, without)
+	}
+}
+
+func TestAllocatorReset(t *testing.T) {
+	src := allocRecords(10)
+	data := gotiny.Marshal(&src)
+	d := gotiny.NewDecoderWithPtr(&src)
+	allocator := gotiny.NewAllocator(0)
+	d.SetAllocator(allocator)
+
+	var first, second []allocT
+	d.Decode(data, &first)
+	allocator.Reset()
+	d.Decode(data, &second)
+	Assert(t, data, src, second)
+	if first[0].ID != second[0].ID {
+		t.Error("expected blocks to be reused after reset")
+	}
+
+	// reused blocks are zeroed, nil pointers and slices stay nil
+	src[0].Next, src[1].Next = nil, &allocT{}
+	data = gotiny.Marshal(&src)
+	allocator.Reset()
+	var third []allocT
+	d.Decode(data, &third)
+	Assert(t, data, src, third)
+}
+
+func TestAllocatorCoder(t *testing.T) {
+	coder := gotiny.New([]allocT{})
+	d := coder.GetDecoder()
+	d.SetAllocator(gotiny.NewAllocator(0))
+	coder.PutDecoder(d)
+	src := allocRecords(3)
+	data := coder.Encode(&src)
+	// decoder taken from coder has no allocator set by previous user
+	d = coder.GetDecoder()
+	var got, again []allocT
+	d.Decode(data, &got)
+	d.Decode(data, &again)
+	if got[0].ID == again[0].ID {
+		t.Error("expected separate allocations without allocator")
+	}
+}
@@ -0,0 +1,153 @@
+package gotiny
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Annotation describes part of data encoded with scheme
+type Annotation struct {
+	Path string // path of value, like [0].Items[2].Name, top level values of coder scheme are [i]
+	// What is type of value for leaf values and "not nil", "length" or "type name"
+	// for headers of pointers, slices, maps, []byte and interfaces
+	What   string
+	Offset int // offset of first byte of value in data
+	Len    int // number of bytes of value, 0 for bool packed into byte of previous bools
+	Bit    int // index of bit of bool in byte at Offset, -1 for values other than bools
+	Value  Value
+}
+
+// Annotate decodes buf encoded with scheme like DecodeDynamic, but returns every part of data with its position.
+// Bools are packed by 8 into one byte, Bit tells where exactly bool is.
+// When data is corrupted or doesn't match scheme, parts decoded before error are returned with error.
+func Annotate(scheme *Scheme, buf []byte) (annotations []Annotation, err error) {
+	a := &annotator{d: &Decoder{buf: buf[:len(buf):len(buf)]}}
+	defer func() {
+		annotations = a.annotations
+	}()
+	defer recoverDynamic(&err, "gotiny: corrupted data: %v")
+	if scheme.isRoot() {
+		for i, child := range scheme.Childs {
+			a.walk(child, "["+strconv.Itoa(i)+"]")
+		}
+	} else {
+		a.walk(scheme, "")
+	}
+	return
+}
+
+type annotator struct {
+	d           *Decoder
+	boolOffset  int // offset of byte with bools being read
+	annotations []Annotation
+}
+
+// walk annotates value of node s, leaf values are returned to name map entries
+func (a *annotator) walk(s *Scheme, path string) Value {
+	d := a.d
+	switch s.Type {
+	case typeIgnore:
+		if s.unknownKind() {
+			panic(dynamicError{s.unknownKindError()})
+		}
+	case typeBool:
+		return a.bool(path, "bool")
+	case typeBytes:
+		if s.isStringNode() {
+			return a.leaf(s, path)
+		}
+		if a.bool(path, "not nil") {
+			l := a.length(path)
+			a.add(path, "bytes", d.index, l, append([]byte{}, d.buf[d.index:d.index+l]...))
+			d.index += l
+		}
+	case typeStruct:
+		for _, child := range s.Childs {
+			fieldPath := child.Name
+			if path != "" {
+				fieldPath = path + "." + child.Name
+			}
+			a.walk(child, fieldPath)
+		}
+	case typePointer:
+		if a.bool(path, "not nil") {
+			a.walk(s.child(0), path)
+		}
+	case typeArray:
+		for i, l := 0, s.arrayLen(); i < l; i++ {
+			a.elem(s, path+"["+strconv.Itoa(i)+"]")
+		}
+	case typeSlice:
+		if a.bool(path, "not nil") {
+			l := a.length(path)
+			for i := 0; i < l; i++ {
+				a.elem(s, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case typeMap:
+		if a.bool(path, "not nil") {
+			l := a.length(path)
+			for i := 0; i < l; i++ {
+				key := a.walk(s.child(0), path+"{key}")
+				a.walk(s.child(1), path+"["+fmt.Sprint(key)+"]")
+			}
+		}
+	case typeInterface:
+		if a.bool(path, "not nil") {
+			start := d.index
+			name := d.decDynamicString()
+			a.add(path, "type name", start, d.index-start, name)
+			impl, ok := s.implScheme(name)
+			if !ok {
+				panic(dynamicErrorf(path, "type %s of interface value is not registered", name))
+			}
+			a.walk(impl, path)
+		}
+	default:
+		return a.leaf(s, path)
+	}
+	return nil
+}
+
+func (a *annotator) leaf(s *Scheme, path string) Value {
+	start := a.d.index
+	v := a.d.decDynamic(s)
+	a.add(path, s.Type.String(), start, a.d.index-start, v)
+	return v
+}
+
+// elem annotates element of slice or array node s
+func (a *annotator) elem(s *Scheme, path string) {
+	if !s.Packed {
+		a.walk(s.child(0), path)
+		return
+	}
+	start := a.d.index
+	v := a.d.decDynamicElem(s)
+	a.add(path, "packed "+s.child(0).Type.String(), start, a.d.index-start, v)
+}
+
+func (a *annotator) bool(path, what string) bool {
+	d, l := a.d, 0
+	if d.boolBit == 0 {
+		a.boolOffset, l = d.index, 1
+	}
+	bit := 0
+	for b := d.boolBit; b > 1; b >>= 1 {
+		bit++
+	}
+	v := d.decBool()
+	a.annotations = append(a.annotations, Annotation{Path: path, What: what, Offset: a.boolOffset, Len: l, Bit: bit, Value: v})
+	return v
+}
+
+func (a *annotator) length(path string) int {
+	start := a.d.index
+	l := a.d.decLength()
+	a.add(path, "length", start, a.d.index-start, l)
+	return l
+}
+
+func (a *annotator) add(path, what string, offset, l int, v Value) {
+	a.annotations = append(a.annotations, Annotation{Path: path, What: what, Offset: offset, Len: l, Bit: -1, Value: v})
+}
@@ -0,0 +1,255 @@
+package gotiny
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ToCBOR converts buf encoded with scheme into CBOR (RFC 8949).
+// Structs are maps keyed by field names, []byte is byte string, time is tag 0 with RFC 3339 string,
+// nil pointers, slices, maps and []byte are null, complex numbers are arrays [real, imag],
+// interfaces are maps {"type": name, "value": value} and values with custom encoding
+// are byte strings with bytes written by gotiny, their types should be registered.
+// Scheme of coder produces array of top level values.
+func ToCBOR(scheme *Scheme, buf []byte) ([]byte, error) {
+	w := &cborWriter{}
+	if err := transcodeTo(w, scheme, buf); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// FromCBOR converts CBOR in form written by ToCBOR into bytes encoded with scheme.
+// Numbers are checked to fit into types of scheme, text strings are accepted for []byte too,
+// time may be tag 1 with seconds since epoch, indefinite lengths are supported.
+func FromCBOR(scheme *Scheme, data []byte) ([]byte, error) {
+	return transcodeFrom(&cborReader{buf: data[:len(data):len(data)]}, scheme)
+}
+
+// major types of CBOR
+const (
+	cborUint = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// cborBreak ends items of indefinite length
+const cborBreak = 0xff
+
+type cborWriter struct {
+	buf []byte
+}
+
+// head writes major type with argument n in the shortest form
+func (w *cborWriter) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		w.buf = append(w.buf, major|26)
+		w.buf = appendUint32(w.buf, uint32(n))
+	default:
+		w.buf = append(w.buf, major|27)
+		w.buf = appendUint64(w.buf, n)
+	}
+}
+
+func (w *cborWriter) writeNil() { w.buf = append(w.buf, cborSimple<<5|22) }
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, cborSimple<<5|21)
+	} else {
+		w.buf = append(w.buf, cborSimple<<5|20)
+	}
+}
+
+func (w *cborWriter) writeInt(i int64) {
+	if i >= 0 {
+		w.head(cborUint, uint64(i))
+	} else {
+		w.head(cborNegInt, uint64(-1-i))
+	}
+}
+
+func (w *cborWriter) writeUint(u uint64) { w.head(cborUint, u) }
+
+func (w *cborWriter) writeFloat(f float64, bits int) {
+	if bits == 32 {
+		w.buf = append(w.buf, cborSimple<<5|26)
+		w.buf = appendUint32(w.buf, math.Float32bits(float32(f)))
+	} else {
+		w.buf = append(w.buf, cborSimple<<5|27)
+		w.buf = appendUint64(w.buf, math.Float64bits(f))
+	}
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.head(cborText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.head(cborBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *cborWriter) writeTime(t time.Time) {
+	w.head(cborTag, 0)
+	w.writeString(t.UTC().Format(time.RFC3339Nano))
+}
+
+func (w *cborWriter) writeArray(n int) { w.head(cborArray, uint64(n)) }
+
+func (w *cborWriter) writeMap(n int) { w.head(cborMap, uint64(n)) }
+
+type cborReader struct {
+	buf []byte
+	i   int
+}
+
+func (r *cborReader) rest() int { return len(r.buf) - r.i }
+
+// next returns next n bytes
+func (r *cborReader) next(n int) []byte {
+	r.i += n
+	return r.buf[r.i-n : r.i]
+}
+
+// head reads major type, additional information and argument, info is 31 for items of indefinite length
+func (r *cborReader) head() (major, info byte, n uint64) {
+	c := r.next(1)[0]
+	major, info = c>>5, c&0x1f
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		for _, b := range r.next(1 << (info - 24)) {
+			n = n<<8 | uint64(b)
+		}
+	case info == 31 && major >= cborBytes && major <= cborMap:
+	default:
+		panic(dynamicError{fmt.Errorf("gotiny: corrupted data: invalid CBOR head 0x%02x", c)})
+	}
+	return
+}
+
+func (r *cborReader) read() interface{} {
+	major, info, n := r.head()
+	indefinite := info == 31
+	switch major {
+	case cborUint:
+		return n
+	case cborNegInt:
+		if n > math.MaxInt64 {
+			panic(dynamicError{fmt.Errorf("gotiny: -1-%d overflows int64", n)})
+		}
+		return -1 - int64(n)
+	case cborBytes:
+		return r.readString(major, n, indefinite)
+	case cborText:
+		return string(r.readString(major, n, indefinite))
+	case cborArray:
+		elems := []interface{}{}
+		if !indefinite {
+			elems = make([]interface{}, 0, readLength(n, r.rest()))
+		}
+		for i := uint64(0); indefinite && !r.isBreak() || !indefinite && i < n; i++ {
+			elems = append(elems, r.read())
+		}
+		return elems
+	case cborMap:
+		entries := []MapEntry{}
+		if !indefinite {
+			entries = make([]MapEntry, 0, readLength(n, r.rest()))
+		}
+		for i := uint64(0); indefinite && !r.isBreak() || !indefinite && i < n; i++ {
+			key := r.read()
+			entries = append(entries, MapEntry{Key: key, Value: r.read()})
+		}
+		return entries
+	case cborTag:
+		return r.readTime(n)
+	}
+	// argument of simple value is its number or bits of float
+	switch {
+	case info == 25:
+		return float16(uint16(n))
+	case info == 26:
+		return float64(math.Float32frombits(uint32(n)))
+	case info == 27:
+		return math.Float64frombits(n)
+	case n == 20:
+		return false
+	case n == 21:
+		return true
+	case n == 22, n == 23: // null and undefined
+		return nil
+	}
+	panic(dynamicError{fmt.Errorf("gotiny: unsupported CBOR simple value %d", n)})
+}
+
+// readString reads bytes of byte or text string, chunks of string of indefinite length are joined
+func (r *cborReader) readString(major byte, n uint64, indefinite bool) []byte {
+	if !indefinite {
+		return append([]byte{}, r.next(readLength(n, r.rest()))...)
+	}
+	b := []byte{}
+	for !r.isBreak() {
+		chunkMajor, info, l := r.head()
+		if chunkMajor != major || info == 31 {
+			panic(dynamicError{fmt.Errorf("gotiny: corrupted data: invalid chunk of CBOR string")})
+		}
+		b = append(b, r.next(readLength(l, r.rest()))...)
+	}
+	return b
+}
+
+// isBreak skips break of item of indefinite length
+func (r *cborReader) isBreak() bool {
+	if r.buf[r.i] == cborBreak {
+		r.i++
+		return true
+	}
+	return false
+}
+
+// readTime reads tagged value, tag 0 is RFC 3339 string and tag 1 is seconds since epoch
+func (r *cborReader) readTime(tag uint64) time.Time {
+	v := r.read()
+	switch t := v.(type) {
+	case string:
+		if tag == 0 {
+			parsed, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				panic(dynamicError{fmt.Errorf("gotiny: %v", err)})
+			}
+			return parsed
+		}
+	case uint64:
+		if tag == 1 && t <= math.MaxInt64 {
+			return time.Unix(int64(t), 0)
+		}
+	case int64:
+		if tag == 1 {
+			return time.Unix(t, 0)
+		}
+	case float64:
+		if tag == 1 {
+			sec, frac := math.Modf(t)
+			return time.Unix(int64(sec), int64(frac*1e9))
+		}
+	}
+	panic(dynamicError{fmt.Errorf("gotiny: unsupported CBOR tag %d of %s", tag, transcodedKind(v))})
+}
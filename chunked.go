@@ -0,0 +1,198 @@
+package gotiny
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// Chunking configures parallel coding of slice by EncodeChunked and DecodeChunked.
+// Data written in chunks is read only in chunks, both sides should use the same ChunkLen sign.
+type Chunking struct {
+	ChunkLen int // number of elements in chunk, chunking is off when it isn't positive
+	Workers  int // number of goroutines, GOMAXPROCS when it isn't positive
+}
+
+func (ch Chunking) workers(chunks int) int {
+	w := ch.Workers
+	if w <= 0 {
+		w = runtime.GOMAXPROCS(0)
+	}
+	if w > chunks {
+		w = chunks
+	}
+	return w
+}
+
+// chunkedSlice returns node of slice coded by coder and type of slice, coder must code one slice
+func (c *Coder) chunkedSlice() (*Scheme, reflect.Type) {
+	if len(c.scheme.Childs) != 1 || c.scheme.Childs[0].Type != typeSlice || len(c.scheme.Childs[0].Childs) != 1 ||
+		len(c.originalScheme.Childs) != 1 || c.originalScheme.Childs[0].rt == nil {
+		panic("gotiny: chunked coding needs coder of one slice")
+	}
+	return c.scheme.Childs[0], c.originalScheme.Childs[0].rt
+}
+
+// EncodeChunked encodes slice pointed by ptr, splitting it into chunks of ch.ChunkLen elements
+// which are encoded by ch.Workers goroutines with encoders of coder.
+// Chunked data starts with not nil flag, length of slice, number of chunks and index of chunks:
+// number of elements and length of data of every chunk, data of chunks follow index.
+// When chunking is off it returns the same data as Encode.
+func (c *Coder) EncodeChunked(ptr interface{}, ch Chunking) []byte {
+	if ch.ChunkLen <= 0 {
+		return c.Encode(ptr)
+	}
+	s, rt := c.chunkedSlice()
+	slice := reflect.NewAt(rt, (*[2]unsafe.Pointer)(unsafe.Pointer(&ptr))[1]).Elem()
+	header := c.GetEncoder()
+	defer c.PutEncoder(header)
+	header.encIsNotNil(!slice.IsNil())
+	if slice.IsNil() {
+		return owned(header.reset())
+	}
+	l, elems := slice.Len(), slice.UnsafePointer()
+	chunks := (l + ch.ChunkLen - 1) / ch.ChunkLen
+	header.encLength(l)
+	header.encLength(chunks)
+
+	eNode, size := s.Childs[0], rt.Elem().Size()
+	data := make([][]byte, chunks)
+	parallel(chunks, ch.workers(chunks), func(i int) {
+		e := c.GetEncoder()
+		start, end := chunkBounds(i, ch.ChunkLen, l)
+		for j := start; j < end; j++ {
+			eNode.encodeEngine(e, unsafe.Add(elems, uintptr(j)*size))
+		}
+		data[i] = owned(e.reset())
+		c.PutEncoder(e)
+	})
+
+	n := 0
+	for i, d := range data {
+		start, end := chunkBounds(i, ch.ChunkLen, l)
+		header.encLength(end - start)
+		header.encLength(len(d))
+		n += len(d)
+	}
+	buf := append(make([]byte, 0, len(header.buf)+n), header.reset()...)
+	for _, d := range data {
+		buf = append(buf, d...)
+	}
+	return buf
+}
+
+// DecodeChunked decodes slice encoded by EncodeChunked into slice pointed by ptr, chunks are decoded
+// by ch.Workers goroutines with decoders of coder, returns length of decoded data.
+// When chunking is off it decodes data written by Encode.
+func (c *Coder) DecodeChunked(buf []byte, ptr interface{}, ch Chunking) (n int, err error) {
+	if ch.ChunkLen <= 0 {
+		defer recoverDynamic(&err, "gotiny: corrupted data: %v")
+		return c.Decode(buf, ptr), nil
+	}
+	s, rt := c.chunkedSlice()
+	slice := reflect.NewAt(rt, (*[2]unsafe.Pointer)(unsafe.Pointer(&ptr))[1]).Elem()
+	index, l, offset, err := c.decodeChunkIndex(buf)
+	if err != nil || index == nil {
+		if err == nil {
+			slice.SetZero()
+		}
+		return offset, err
+	}
+	if slice.IsNil() || slice.Cap() < l {
+		slice.Set(reflect.MakeSlice(rt, l, l))
+	} else {
+		slice.SetLen(l)
+	}
+	elems := slice.UnsafePointer()
+
+	eNode, size := s.Childs[0], rt.Elem().Size()
+	errs := make([]error, len(index))
+	parallel(len(index), ch.workers(len(index)), func(i int) {
+		ck := index[i]
+		d := c.GetDecoder()
+		d.buf = buf[ck.offset:ck.next:ck.next]
+		func() {
+			defer recoverDynamic(&errs[i], "gotiny: corrupted chunk "+strconv.Itoa(i)+": %v")
+			for j := ck.start; j < ck.end; j++ {
+				eNode.decodeEngine(d, unsafe.Add(elems, uintptr(j)*size))
+			}
+		}()
+		if read := d.reset(); errs[i] == nil && read != ck.next-ck.offset {
+			errs[i] = fmt.Errorf("gotiny: corrupted chunk %d: %d bytes of %d are decoded", i, read, ck.next-ck.offset)
+		}
+		c.PutDecoder(d)
+	})
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// chunk is entry of chunk index, elements start:end are encoded in buf[offset:next]
+type chunk struct{ start, end, offset, next int }
+
+// decodeChunkIndex returns index of chunks of slice of length l, nil index for nil slice, and length of data
+func (c *Coder) decodeChunkIndex(buf []byte) (index []chunk, l, n int, err error) {
+	d := c.GetDecoder()
+	d.buf = buf
+	defer func() {
+		d.reset()
+		c.PutDecoder(d)
+	}()
+	defer recoverDynamic(&err, "gotiny: corrupted chunk index: %v")
+	if !d.decIsNotNil() {
+		return nil, 0, d.index, nil
+	}
+	l = d.decLength()
+	chunks := d.decLength()
+	_ = d.rest()[:2*chunks] // entry of index takes at least 2 bytes, corrupted number of chunks shouldn't allocate more
+	index = make([]chunk, chunks)
+	start := 0
+	for i := range index {
+		elems, length := d.decLength(), d.decLength()
+		index[i] = chunk{start: start, end: start + elems, next: length}
+		start += elems
+	}
+	n = d.index
+	for i := range index {
+		index[i].offset, n = n, n+index[i].next
+		index[i].next = n
+	}
+	if start != l || n > len(buf) {
+		return nil, 0, 0, fmt.Errorf("gotiny: corrupted chunk index: %d elements of %d are in chunks, data of chunks ends at %d of %d", start, l, n, len(buf))
+	}
+	return index, l, n, nil
+}
+
+// chunkBounds returns range of elements of chunk i
+func chunkBounds(i, chunkLen, l int) (start, end int) {
+	start, end = i*chunkLen, (i+1)*chunkLen
+	if end > l {
+		end = l
+	}
+	return
+}
+
+// parallel calls f for every number below n on workers goroutines
+func parallel(n, workers int, f func(i int)) {
+	var wg sync.WaitGroup
+	next := make(chan int, n)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,131 @@
+package gotiny_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+// chunkedT has bools, which share bytes between elements when slice isn't chunked
+type chunkedT struct {
+	ID     int
+	Active bool
+	Name   string
+	Tags   []string
+	Seen   *bool
+}
+
+func chunkedRecords(n int) []chunkedT {
+	records := make([]chunkedT, n)
+	for i := range records {
+		records[i] = chunkedT{ID: i, Active: i%3 == 0, Name: "record " + strconv.Itoa(i)}
+		if i%5 == 0 {
+			records[i].Tags = []string{"a", "b"}
+			records[i].Seen = &records[i].Active
+		}
+	}
+	return records
+}
+
+func TestChunked(t *testing.T) {
+	coder := gotiny.New([]chunkedT{})
+	for _, n := range []int{0, 1, 99, 100, 101, 1000} {
+		src := chunkedRecords(n)
+		for _, ch := range []gotiny.Chunking{{ChunkLen: 100}, {ChunkLen: 7, Workers: 3}, {ChunkLen: 1, Workers: 1}} {
+			data := coder.EncodeChunked(&src, ch)
+			var got []chunkedT
+			read, err := coder.DecodeChunked(data, &got, ch)
+			if err != nil || read != len(data) {
+				t.Fatalf("%d %+v: decoded %d bytes of %d: %v", n, ch, read, len(data), err)
+			}
+			Assert(t, data, src, got)
+		}
+	}
+
+	// chunks don't depend on number of workers
+	src := chunkedRecords(500)
+	if a, b := coder.EncodeChunked(&src, gotiny.Chunking{ChunkLen: 64, Workers: 1}), coder.EncodeChunked(&src, gotiny.Chunking{ChunkLen: 64}); !bytes.Equal(a, b) {
+		t.Error("expected the same data with different number of workers")
+	}
+
+	var nilSlice []chunkedT
+	data := coder.EncodeChunked(&nilSlice, gotiny.Chunking{ChunkLen: 10})
+	got := []chunkedT{{}}
+	if _, err := coder.DecodeChunked(data, &got, gotiny.Chunking{ChunkLen: 10}); err != nil || got != nil {
+		t.Errorf("expected nil slice, got %v %v", got, err)
+	}
+}
+
+func TestChunkedOff(t *testing.T) {
+	coder := gotiny.New([]chunkedT{})
+	src := chunkedRecords(300)
+	data := coder.EncodeChunked(&src, gotiny.Chunking{})
+	if exp := coder.Encode(&src); !bytes.Equal(data, exp) {
+		t.Error("expected data of Encode when chunking is off")
+	}
+	var got []chunkedT
+	if read, err := coder.DecodeChunked(data, &got, gotiny.Chunking{Workers: 4}); err != nil || read != len(data) {
+		t.Fatalf("decoded %d bytes of %d: %v", read, len(data), err)
+	}
+	Assert(t, data, src, got)
+}
+
+func TestChunkedReuse(t *testing.T) {
+	coder := gotiny.New([]chunkedT{})
+	src := chunkedRecords(200)
+	ch := gotiny.Chunking{ChunkLen: 16}
+	data := coder.EncodeChunked(&src, ch)
+	got := make([]chunkedT, 0, 300)
+	if _, err := coder.DecodeChunked(data, &got, ch); err != nil {
+		t.Fatal(err)
+	}
+	if cap(got) != 300 {
+		t.Errorf("expected decoding into slice with capacity, got capacity %d", cap(got))
+	}
+	Assert(t, data, src, got)
+}
+
+func TestChunkedCorrupted(t *testing.T) {
+	coder := gotiny.New([]chunkedT{})
+	src := chunkedRecords(50)
+	ch := gotiny.Chunking{ChunkLen: 10}
+	data := coder.EncodeChunked(&src, ch)
+	for _, c := range []struct {
+		data []byte
+		err  string
+	}{
+		{data[:3], "corrupted chunk index"},
+		{data[:len(data)-1], "corrupted chunk index"},
+		{append(append([]byte{}, data...), 0), ""},
+		{[]byte{1, 5, 0x7f, 0x7f}, "corrupted chunk index"},
+	} {
+		var got []chunkedT
+		_, err := coder.DecodeChunked(c.data, &got, ch)
+		if c.err == "" && err != nil || c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("expected error %q, got %v", c.err, err)
+		}
+	}
+
+	// lengths of chunks are swapped, so chunks are decoded from wrong data
+	other := coder.EncodeChunked(&[]chunkedT{{ID: 1}, {Name: strings.Repeat("x", 100)}}, gotiny.Chunking{ChunkLen: 1})
+	if !bytes.HasPrefix(other, []byte{1, 2, 2, 1, 3, 1, 103}) {
+		t.Fatalf("unexpected chunk index %v", other[:7])
+	}
+	other[4], other[6] = 103, 3
+	var got []chunkedT
+	if _, err := coder.DecodeChunked(other, &got, gotiny.Chunking{ChunkLen: 1}); err == nil || !strings.Contains(err.Error(), "corrupted chunk 0") {
+		t.Errorf("expected error for corrupted chunk, got %v", err)
+	}
+}
+
+func TestChunkedNotSlice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for coder of not slice")
+		}
+	}()
+	gotiny.New(chunkedT{}).EncodeChunked(&chunkedT{}, gotiny.Chunking{ChunkLen: 1})
+}
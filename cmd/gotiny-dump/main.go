@@ -0,0 +1,119 @@
+// Command gotiny-dump prints every part of data encoded by gotiny with its position,
+// using only scheme of data produced by Scheme.AsJSON.
+//
+// Usage:
+//
+//	gotiny-dump scheme.json [data]
+//
+// Every line has offset and raw bytes of value, its path, what it is and decoded value.
+// Bools are packed by 8 into one byte, so bit of bool in byte is printed too.
+// Headers of pointers, slices, maps, []byte and interfaces are printed as separate lines.
+// When data is corrupted, lines decoded before error are printed and gotiny-dump exits with status 1.
+// Data is read from stdin when file name is omitted or "-".
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+// maxRaw is number of raw bytes printed for one value
+const maxRaw = 16
+
+func main() {
+	flag.Usage = func() { usage(os.Stderr) }
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes command with arguments and returns exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 1 && len(args) != 2 {
+		usage(stderr)
+		return 2
+	}
+
+	schemeJSON, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return fail(stderr, 2, err)
+	}
+	scheme, err := gotiny.SchemeFromJSON(string(schemeJSON))
+	if err != nil {
+		return fail(stderr, 2, fmt.Errorf("%s: %v", args[0], err))
+	}
+	var data []byte
+	if len(args) == 1 || args[1] == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(args[1])
+	}
+	if err != nil {
+		return fail(stderr, 2, err)
+	}
+
+	annotations, err := gotiny.Annotate(scheme, data)
+	dump(stdout, data, annotations)
+	if err != nil {
+		return fail(stderr, 1, err)
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage:")
+	fmt.Fprintln(w, "  gotiny-dump scheme.json [data]")
+}
+
+func fail(stderr io.Writer, status int, err error) int {
+	fmt.Fprintln(stderr, "gotiny-dump:", err)
+	return status
+}
+
+// dump prints annotations as table, bytes after the last value are printed as trailing
+func dump(w io.Writer, data []byte, annotations []gotiny.Annotation) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "offset\tlen\traw\tpath\twhat\tvalue")
+	end := 0
+	for _, a := range annotations {
+		what, l := a.What, a.Len
+		if a.Bit >= 0 {
+			what += " bit " + strconv.Itoa(a.Bit)
+			l = 1
+		}
+		fmt.Fprintf(tw, "%06x\t%d\t%s\t%s\t%s\t%s\n", a.Offset, a.Len, raw(data[a.Offset:a.Offset+l]), a.Path, what, value(a.Value))
+		if a.Offset+l > end {
+			end = a.Offset + l
+		}
+	}
+	if end < len(data) {
+		fmt.Fprintf(tw, "%06x\t%d\t%s\t\ttrailing bytes\t\n", end, len(data)-end, raw(data[end:]))
+	}
+	tw.Flush()
+}
+
+func raw(b []byte) string {
+	if len(b) > maxRaw {
+		return hex.EncodeToString(b[:maxRaw]) + "..."
+	}
+	return hex.EncodeToString(b)
+}
+
+func value(v gotiny.Value) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return strconv.Quote(string(v))
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/item.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		args   []string
+		stdin  string
+		status int
+		out    []string // lines expected in output
+		errOut string
+	}{
+		{args: []string{"testdata/item.json", "testdata/item.bin"}, out: []string{
+			"offset  len  raw         path         what           value\n" +
+				"000000  4    0370656e    [0].Name     string         \"pen\"\n" +
+				"000004  1    06          [0].Count    int32          3\n" +
+				"000005  1    07          [0].Tags     not nil bit 0  true\n",
+			"000005  0    07          [0].Active   bool bit 1     true\n",
+			"000011  2    0102        [0].Raw      bytes          \"\\x01\\x02\"\n",
+		}},
+		{args: []string{"testdata/item.json"}, stdin: string(data), out: []string{"[0].Tags[1]  string         \"blue\""}},
+		{args: []string{"testdata/item.json", "-"}, stdin: string(data) + "\x05", out: []string{"000013  1    05", "trailing bytes"}},
+		{args: []string{"testdata/item.json", "-"}, stdin: string(data[:9]), status: 1, out: []string{"000006  1    02"}, errOut: "gotiny-dump: gotiny: corrupted data"},
+		{args: []string{"testdata/missing.json"}, status: 2, errOut: "gotiny-dump: open testdata/missing.json"},
+		{args: []string{"testdata/item.bin"}, status: 2, errOut: "gotiny-dump: testdata/item.bin: "},
+		{args: nil, status: 2, errOut: "usage:"},
+	} {
+		var stdout, stderr bytes.Buffer
+		status := run(c.args, strings.NewReader(c.stdin), &stdout, &stderr)
+		if status != c.status {
+			t.Errorf("%v: expected status %d, got %d, %s", c.args, c.status, status, stderr.String())
+		}
+		for _, exp := range c.out {
+			if !strings.Contains(stdout.String(), exp) {
+				t.Errorf("%v: expected output to contain\n%s\ngot\n%s", c.args, exp, stdout.String())
+			}
+		}
+		if !strings.Contains(stderr.String(), c.errOut) {
+			t.Errorf("%v: expected error output %q, got %q", c.args, c.errOut, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Command gotiny-idl converts .tiny files into schemes used by other gotiny tools.
+//
+// Usage:
+//
+//	gotiny-idl file.tiny [Type...]
+//
+// It prints scheme produced by Scheme.AsJSON of coder of values of listed types,
+// of all structs declared in file when types are omitted, see package idl for syntax of file.
+// Output may be given to gotiny-json, gotiny-dump and gotiny-scheme, for example to generate go types.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/niubaoshu/gotiny/idl"
+)
+
+func main() {
+	flag.Usage = func() { usage(os.Stderr) }
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// run executes command with arguments and returns exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	f, err := idl.ParseFile(args[0])
+	if err != nil {
+		return fail(stderr, err)
+	}
+	scheme, err := f.Scheme(args[1:]...)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	fmt.Fprintln(stdout, scheme.AsJSON())
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gotiny-idl file.tiny [Type...]")
+}
+
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, "gotiny-idl:", err)
+	return 1
+}
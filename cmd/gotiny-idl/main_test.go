@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	for _, c := range []struct {
+		args   []string
+		status int
+		out    []string // parts expected in output
+		errOut string
+	}{
+		{args: []string{"testdata/shapes.tiny", "Drawing"}, out: []string{
+			`{"childs":[{"type":"struct","goType":"example.com/shapes.Drawing","childs":[{"name":"Name","type":"string","goType":"string"},`,
+			`"impls":["example.com/shapes.Circle","example.com/shapes.Square"]`,
+		}},
+		{args: []string{"testdata/shapes.tiny"}, out: []string{
+			`{"childs":[{"type":"struct","goType":"example.com/shapes.Circle",`, `{"type":"struct","goType":"example.com/shapes.Drawing"`,
+		}},
+		{args: []string{"testdata/shapes.tiny", "Missing"}, status: 1, errOut: "gotiny-idl: idl: type Missing is not declared"},
+		{args: []string{"testdata/broken.tiny"}, status: 1, errOut: `gotiny-idl: testdata/broken.tiny:2:27: expected type, got "}"`},
+		{args: []string{"testdata/missing.tiny"}, status: 1, errOut: "gotiny-idl: open testdata/missing.tiny"},
+		{args: nil, status: 2, errOut: "usage:"},
+	} {
+		var stdout, stderr bytes.Buffer
+		status := run(c.args, &stdout, &stderr)
+		if status != c.status {
+			t.Errorf("%v: expected status %d, got %d, %s", c.args, c.status, status, stderr.String())
+		}
+		for _, exp := range c.out {
+			if !strings.Contains(stdout.String(), exp) {
+				t.Errorf("%v: expected output to contain\n%s\ngot\n%s", c.args, exp, stdout.String())
+			}
+		}
+		if !strings.Contains(stderr.String(), c.errOut) {
+			t.Errorf("%v: expected error output %q, got %q", c.args, c.errOut, stderr.String())
+		}
+	}
+}
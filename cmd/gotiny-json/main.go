@@ -0,0 +1,89 @@
+// Command gotiny-json converts data encoded by gotiny into JSON and back,
+// using only scheme of data produced by Scheme.AsJSON.
+//
+// Usage:
+//
+//	gotiny-json tojson scheme.json [data]
+//	gotiny-json fromjson scheme.json [data.json]
+//
+// tojson prints indented JSON of data, see gotiny.ToJSON for its form,
+// fromjson prints data encoded from JSON, which may be edited output of tojson.
+// Data is read from stdin when file name is omitted or "-".
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+func main() {
+	flag.Usage = func() { usage(os.Stderr) }
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes command with arguments and returns exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 || len(args) > 3 || args[0] != "tojson" && args[0] != "fromjson" {
+		usage(stderr)
+		return 2
+	}
+
+	schemeJSON, err := read(args[1], stdin)
+	if err != nil {
+		return fail(stderr, 2, err)
+	}
+	scheme, err := gotiny.SchemeFromJSON(string(schemeJSON))
+	if err != nil {
+		return fail(stderr, 2, fmt.Errorf("%s: %v", args[1], err))
+	}
+	name := "-"
+	if len(args) == 3 {
+		name = args[2]
+	}
+	data, err := read(name, stdin)
+	if err != nil {
+		return fail(stderr, 2, err)
+	}
+
+	if args[0] == "tojson" {
+		if data, err = gotiny.ToJSON(scheme, data); err == nil {
+			var indented bytes.Buffer
+			json.Indent(&indented, data, "", "  ")
+			indented.WriteByte('\n')
+			data = indented.Bytes()
+		}
+	} else {
+		data, err = gotiny.FromJSON(scheme, data)
+	}
+	if err != nil {
+		return fail(stderr, 1, err)
+	}
+	stdout.Write(data)
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage:")
+	fmt.Fprintln(w, "  gotiny-json tojson scheme.json [data]")
+	fmt.Fprintln(w, "  gotiny-json fromjson scheme.json [data.json]")
+}
+
+func read(name string, stdin io.Reader) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+func fail(stderr io.Writer, status int, err error) int {
+	fmt.Fprintln(stderr, "gotiny-json:", err)
+	return status
+}
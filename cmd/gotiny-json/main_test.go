@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/item.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemJSON := `[{"Name":"pen","Count":3,"Tags":["red","blue"],"Active":true,"Raw":"AQI="}]`
+	for _, c := range []struct {
+		args   []string
+		stdin  string
+		status int
+		out    []string // parts expected in output
+		errOut string
+	}{
+		{args: []string{"tojson", "testdata/item.json", "testdata/item.bin"}, out: []string{"[\n  {\n    \"Name\": \"pen\",\n    \"Count\": 3,\n", `"Raw": "AQI="`}},
+		{args: []string{"tojson", "testdata/item.json"}, stdin: string(data), out: []string{`"Active": true`}},
+		{args: []string{"tojson", "testdata/item.json", "-"}, stdin: string(data[:4]), status: 1, errOut: "gotiny-json: gotiny: corrupted data"},
+		{args: []string{"fromjson", "testdata/item.json"}, stdin: itemJSON, out: []string{string(data)}},
+		{args: []string{"fromjson", "testdata/item.json"}, stdin: `[{"Count":"three"}]`, status: 1, errOut: "gotiny-json:"},
+		{args: []string{"tojson", "testdata/missing.json"}, status: 2, errOut: "gotiny-json: open testdata/missing.json"},
+		{args: []string{"tojson", "-", "testdata/item.bin"}, stdin: `{"type":"unknown"}`, status: 2, errOut: "gotiny-json: -: "},
+		{args: []string{"tojson"}, status: 2, errOut: "usage:"},
+		{args: []string{"toxml", "testdata/item.json"}, status: 2, errOut: "usage:"},
+	} {
+		var stdout, stderr bytes.Buffer
+		status := run(c.args, strings.NewReader(c.stdin), &stdout, &stderr)
+		if status != c.status {
+			t.Errorf("%v: expected status %d, got %d, %s", c.args, c.status, status, stderr.String())
+		}
+		for _, exp := range c.out {
+			if !strings.Contains(stdout.String(), exp) {
+				t.Errorf("%v: expected output to contain\n%q\ngot\n%q", c.args, exp, stdout.String())
+			}
+		}
+		if !strings.Contains(stderr.String(), c.errOut) {
+			t.Errorf("%v: expected error output %q, got %q", c.args, c.errOut, stderr.String())
+		}
+	}
+}
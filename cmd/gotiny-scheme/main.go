@@ -0,0 +1,201 @@
+// Command gotiny-scheme prints and compares schemes produced by Scheme.AsJSON.
+//
+// Usage:
+//
+//	gotiny-scheme dump scheme.json
+//	gotiny-scheme diff writer.json reader.json
+//	gotiny-scheme gen scheme.json [package]
+//	gotiny-scheme jsonschema scheme.json
+//
+// dump prints scheme as an indented tree, diff prints every difference
+// between scheme used to encode data and scheme data is decoded with,
+// together with compatibility verdict. diff exits with status 1 when
+// at least one change is breaking, so it can be used in CI.
+// gen prints go source of types having the scheme, see Scheme.GoSource.
+// jsonschema prints JSON Schema of data converted by gotiny.ToJSON, see Scheme.JSONSchema.
+// "-" may be used instead of file name to read scheme from stdin.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+func main() {
+	flag.Usage = func() { usage(os.Stderr) }
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes command with arguments and returns exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	files := 0 // number of schemes in arguments
+	if len(args) > 0 {
+		switch {
+		case args[0] == "dump" && len(args) == 2, args[0] == "jsonschema" && len(args) == 2:
+			files = 1
+		case args[0] == "gen" && (len(args) == 2 || len(args) == 3):
+			files = 1
+		case args[0] == "diff" && len(args) == 3:
+			files = 2
+		}
+	}
+	if files == 0 {
+		usage(stderr)
+		return 2
+	}
+	schemes := make([]*gotiny.Scheme, files)
+	for i := range schemes {
+		var err error
+		if schemes[i], err = read(args[i+1], stdin); err != nil {
+			fmt.Fprintln(stderr, "gotiny-scheme:", args[i+1]+":", err)
+			return 2
+		}
+	}
+
+	switch args[0] {
+	case "dump":
+		dump(stdout, schemes[0])
+	case "diff":
+		if diff(stdout, schemes[0], schemes[1]) {
+			return 1
+		}
+	case "gen":
+		pkg := "scheme"
+		if len(args) == 3 {
+			pkg = args[2]
+		}
+		src, err := schemes[0].GoSource(pkg)
+		if err != nil {
+			fmt.Fprintln(stderr, "gotiny-scheme:", err)
+			return 1
+		}
+		stdout.Write(src)
+	case "jsonschema":
+		src, err := schemes[0].JSONSchema()
+		if err != nil {
+			fmt.Fprintln(stderr, "gotiny-scheme:", err)
+			return 1
+		}
+		stdout.Write(append(src, '\n'))
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage:")
+	fmt.Fprintln(w, "  gotiny-scheme dump scheme.json")
+	fmt.Fprintln(w, "  gotiny-scheme diff writer.json reader.json")
+	fmt.Fprintln(w, "  gotiny-scheme gen scheme.json [package]")
+	fmt.Fprintln(w, "  gotiny-scheme jsonschema scheme.json")
+}
+
+// read reads scheme from file, "-" is stdin
+func read(name string, stdin io.Reader) (*gotiny.Scheme, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gotiny.SchemeFromJSON(string(data))
+}
+
+// dump prints scheme tree, top level values of coder scheme are labeled by index
+func dump(w io.Writer, scheme *gotiny.Scheme) {
+	if scheme.Name == "" && scheme.Type == gotiny.TypeIgnore && len(scheme.Childs) > 0 {
+		for i, child := range scheme.Childs {
+			dumpNode(w, "["+strconv.Itoa(i)+"]", child, 0, map[string]bool{})
+		}
+		return
+	}
+	dumpNode(w, scheme.Name, scheme, 0, map[string]bool{})
+}
+
+// dumpNode prints node and its children, path keeps types of enclosing nodes to stop on recursive types
+func dumpNode(w io.Writer, label string, node *gotiny.Scheme, depth int, path map[string]bool) {
+	line := strings.Repeat("  ", depth)
+	if label != "" {
+		line += label + " "
+	}
+	key := node.GoType
+	if key == "" {
+		key = fmt.Sprintf("%p", node)
+	}
+	if path[key] {
+		fmt.Fprintln(w, line+"-> "+node.GoType+" (recursive)")
+		return
+	}
+	line += node.Type.String()
+	if node.Type == gotiny.TypeArray {
+		line += "[" + strconv.Itoa(node.Len) + "]"
+	}
+	if node.GoType != "" && node.GoType != node.Type.String() {
+		line += " (" + node.GoType + ")"
+	}
+	if len(node.Impls) > 0 {
+		line += " impls: " + strings.Join(node.Impls, ", ")
+	}
+	fmt.Fprintln(w, line)
+	path[key] = true
+	for i, child := range node.Childs {
+		dumpNode(w, childLabel(node, i), child, depth+1, path)
+	}
+	delete(path, key)
+}
+
+func childLabel(node *gotiny.Scheme, i int) string {
+	switch node.Type {
+	case gotiny.TypeStruct:
+		return node.Childs[i].Name
+	case gotiny.TypePointer:
+		return "*"
+	case gotiny.TypeMap:
+		if i == 0 {
+			return "{key}"
+		}
+		return "{value}"
+	case gotiny.TypeSlice, gotiny.TypeArray:
+		return "[]"
+	}
+	return node.Childs[i].Name
+}
+
+// diff prints changes between schemes and reports whether any of them is breaking
+func diff(w io.Writer, writer, reader *gotiny.Scheme) bool {
+	report := gotiny.CheckCompatibility(writer, reader)
+	if len(report.Changes) == 0 {
+		fmt.Fprintln(w, "schemes are identical")
+		return false
+	}
+	for _, change := range report.Changes {
+		fmt.Fprintf(w, "%s %s\n", marker(change), change)
+	}
+	if report.Breaking() {
+		fmt.Fprintln(w, "verdict: breaking")
+		return true
+	}
+	fmt.Fprintln(w, "verdict: compatible")
+	return false
+}
+
+func marker(change gotiny.Change) string {
+	switch change.Kind {
+	case gotiny.FieldAdded:
+		return "+"
+	case gotiny.FieldRemoved:
+		return "-"
+	}
+	return "~"
+}
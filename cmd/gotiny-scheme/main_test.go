@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	item, err := ioutil.ReadFile("testdata/item.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		args   []string
+		stdin  string
+		status int
+		out    []string // lines expected in output
+		errOut string
+	}{
+		{args: []string{"dump", "testdata/item.json"}, out: []string{
+			"[0] struct (main.Item)\n  Name string\n  Count int32\n  Tags slice ([]string)\n    [] string\n" +
+				"  Grid array[2] ([2]uint8)\n    [] uint8\n  Next pointer (*main.Item)\n    * -> main.Item (recursive)\n" +
+				"  Attrs map (map[string]bool)\n    {key} string\n    {value} bool\n",
+		}},
+		{args: []string{"dump", "-"}, stdin: string(item), out: []string{"[0] struct (main.Item)"}},
+		{args: []string{"diff", "testdata/item.json", "testdata/item.json"}, out: []string{"schemes are identical"}},
+		{args: []string{"diff", "testdata/item.json", "testdata/item_extra.json"}, out: []string{
+			"+ [0].Extra: added (backward-compatible): keeps zero value", "verdict: compatible",
+		}},
+		{args: []string{"diff", "testdata/item.json", "testdata/item_v2.json"}, status: 1, out: []string{
+			"~ [0].Count: type changed (breaking): int32 -> int64, value is dropped", "verdict: breaking",
+		}},
+		{args: []string{"gen", "testdata/item.json", "model"}, out: []string{"package model", "\tNext  *Item\n", "\tGrid  [2]uint8\n"}},
+		{args: []string{"gen", "testdata/item.json"}, out: []string{"package scheme"}},
+		{args: []string{"jsonschema", "testdata/item.json"}, out: []string{`"minItems": 2`, `"$ref": "#/$defs/Item"`}},
+		{args: []string{"dump", "testdata/missing.json"}, status: 2, errOut: "gotiny-scheme: testdata/missing.json:"},
+		{args: []string{"dump", "-"}, stdin: `{"type":"unknown"}`, status: 2, errOut: "unknown scheme type"},
+		{args: []string{"diff", "testdata/item.json"}, status: 2, errOut: "usage:"},
+		{args: nil, status: 2, errOut: "usage:"},
+	} {
+		var stdout, stderr bytes.Buffer
+		status := run(c.args, strings.NewReader(c.stdin), &stdout, &stderr)
+		if status != c.status {
+			t.Errorf("%v: expected status %d, got %d, %s", c.args, c.status, status, stderr.String())
+		}
+		for _, exp := range c.out {
+			if !strings.Contains(stdout.String(), exp) {
+				t.Errorf("%v: expected output to contain\n%s\ngot\n%s", c.args, exp, stdout.String())
+			}
+		}
+		if !strings.Contains(stderr.String(), c.errOut) {
+			t.Errorf("%v: expected error output %q, got %q", c.args, c.errOut, stderr.String())
+		}
+	}
+}
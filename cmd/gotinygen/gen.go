@@ -0,0 +1,571 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	errorType = types.Universe.Lookup("error").Type()
+	byteSlice = types.NewSlice(types.Typ[types.Byte])
+
+	// interfaces checked in the same order as implementOtherSerializer of gotiny does
+	gotinySerializer = newInterface(
+		newMethod("GotinyEncode", []types.Type{byteSlice}, []types.Type{byteSlice}),
+		newMethod("GotinyDecode", []types.Type{byteSlice}, []types.Type{types.Typ[types.Int]}))
+	intPtr, bytePtr      = types.NewPointer(types.Typ[types.Int]), types.NewPointer(types.Typ[types.Byte])
+	gotinyBoolSerializer = newInterface(
+		newMethod("GotinyEncode", []types.Type{byteSlice}, []types.Type{byteSlice}),
+		newMethod("GotinyDecode", []types.Type{byteSlice}, []types.Type{types.Typ[types.Int]}),
+		newMethod("GotinyEncodeBools", []types.Type{byteSlice, intPtr, bytePtr}, []types.Type{byteSlice}),
+		newMethod("GotinyDecodeBools", []types.Type{byteSlice, bytePtr, bytePtr}, []types.Type{types.Typ[types.Int]}))
+	binarySerializer = newInterface(
+		newMethod("MarshalBinary", nil, []types.Type{byteSlice, errorType}),
+		newMethod("UnmarshalBinary", []types.Type{byteSlice}, []types.Type{errorType}))
+	gobSerializer = newInterface(
+		newMethod("GobEncode", nil, []types.Type{byteSlice, errorType}),
+		newMethod("GobDecode", []types.Type{byteSlice}, []types.Type{errorType}))
+)
+
+func newMethod(name string, params, results []types.Type) *types.Func {
+	vars := func(ts []types.Type) *types.Tuple {
+		vs := make([]*types.Var, len(ts))
+		for i, t := range ts {
+			vs[i] = types.NewParam(0, nil, "", t)
+		}
+		return types.NewTuple(vs...)
+	}
+	return types.NewFunc(0, nil, name, types.NewSignatureType(nil, nil, nil, vars(params), vars(results), false))
+}
+
+func newInterface(methods ...*types.Func) *types.Interface {
+	return types.NewInterfaceType(methods, nil).Complete()
+}
+
+type serializer int
+
+const (
+	noSerializer      serializer = iota
+	gotinyBoolMethods            // generated methods sharing bools with enclosing value
+	gotinyMethods
+	binaryMethods
+	gobMethods
+)
+
+// generator writes code of one package, named types without serializer methods
+// get pair of helper functions which are shared by all types using them
+type generator struct {
+	pkg      *types.Package
+	targets  []*types.Named
+	isTarget map[*types.Named]bool
+	imports  map[string]string // package names by path
+	helpers  map[*types.Named]string
+	queue    []*types.Named // types helpers are used but not written yet
+	tmps     int
+	err      error
+}
+
+func newGenerator(pkg *types.Package, targets []*types.Named) *generator {
+	g := &generator{
+		pkg:      pkg,
+		targets:  targets,
+		isTarget: map[*types.Named]bool{},
+		imports:  map[string]string{},
+		helpers:  map[*types.Named]string{},
+	}
+	for _, t := range targets {
+		g.isTarget[t] = true
+	}
+	return g
+}
+
+func (g *generator) fail(path, format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+	}
+}
+
+// use returns name the package with path is referred by in generated file
+func (g *generator) use(path, name string) string {
+	if n, ok := g.imports[path]; ok {
+		return n
+	}
+	n := name
+	for i := 2; g.nameUsed(n); i++ {
+		n = name + strconv.Itoa(i)
+	}
+	g.imports[path] = n
+	return n
+}
+
+func (g *generator) nameUsed(name string) bool {
+	for _, n := range g.imports {
+		if n == name {
+			return true
+		}
+	}
+	return g.pkg.Scope().Lookup(name) != nil
+}
+
+func (g *generator) typ(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		return g.use(p.Path(), p.Name())
+	})
+}
+
+func (g *generator) tmp(name string) string {
+	g.tmps++
+	return name + strconv.Itoa(g.tmps)
+}
+
+// serializerOf reports which serializer methods *t has, as gotiny does it checks method set of pointer,
+// so methods promoted from embedded fields are taken into account
+func (g *generator) serializerOf(t types.Type) serializer {
+	if named, ok := t.(*types.Named); ok && g.isTarget[named] {
+		return gotinyBoolMethods
+	}
+	pt := types.NewPointer(t)
+	switch {
+	case types.Implements(pt, gotinyBoolSerializer):
+		return gotinyBoolMethods
+	case types.Implements(pt, gotinySerializer):
+		return gotinyMethods
+	case types.Implements(pt, binarySerializer):
+		return binaryMethods
+	case types.Implements(pt, gobSerializer):
+		return gobMethods
+	}
+	return noSerializer
+}
+
+// isTime reports whether t is time.Time, which has its own engine
+func isTime(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+// isPredeclared reports whether gotiny has engine of t in its cache, such types are never checked for methods
+func isPredeclared(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return true
+	case *types.Slice:
+		return types.Identical(t, byteSlice)
+	case *types.Struct:
+		return t.NumFields() == 0
+	}
+	return isTime(t)
+}
+
+func (g *generator) file() ([]byte, error) {
+	var body bytes.Buffer
+	for _, t := range g.targets {
+		if g.implementsOther(t) {
+			return nil, fmt.Errorf("%s already has serializer methods", t.Obj().Name())
+		}
+		name := t.Obj().Name()
+		g.tmps = 0
+		fmt.Fprintf(&body, "\n// GotinyEncode appends encoded value to buf, it implements gotiny.GoTinySerializer.\n")
+		fmt.Fprintf(&body, "func (v *%s) GotinyEncode(buf []byte) []byte {\nvar boolPos int\nvar boolBit byte\nreturn v.GotinyEncodeBools(buf, &boolPos, &boolBit)\n}\n", name)
+		fmt.Fprintf(&body, "\n// GotinyEncodeBools appends encoded value to buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.\n")
+		fmt.Fprintf(&body, "func (v *%s) GotinyEncodeBools(buf []byte, boolPos *int, boolBit *byte) []byte {\ne := &gotinyEncoder{buf: buf, boolPos: *boolPos, boolBit: *boolBit}\n%s*boolPos, *boolBit = e.boolPos, e.boolBit\nreturn e.buf\n}\n", name, g.encUnderlying(t, "(*v)", name))
+		g.tmps = 0
+		fmt.Fprintf(&body, "\n// GotinyDecode decodes value from buf and returns number of bytes read, it implements gotiny.GoTinySerializer.\n")
+		fmt.Fprintf(&body, "func (v *%s) GotinyDecode(buf []byte) int {\nvar bools, boolBit byte\nreturn v.GotinyDecodeBools(buf, &bools, &boolBit)\n}\n", name)
+		fmt.Fprintf(&body, "\n// GotinyDecodeBools decodes value from buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.\n")
+		fmt.Fprintf(&body, "func (v *%s) GotinyDecodeBools(buf []byte, bools, boolBit *byte) int {\nd := &gotinyDecoder{buf: buf, boolPos: *bools, boolBit: *boolBit}\n%s*bools, *boolBit = d.boolPos, d.boolBit\nreturn d.index\n}\n", name, g.decUnderlying(t, "(*v)", name))
+	}
+	for len(g.queue) > 0 {
+		t := g.queue[0]
+		g.queue = g.queue[1:]
+		name, typ := g.helpers[t], g.typ(t)
+		g.tmps = 0
+		fmt.Fprintf(&body, "\nfunc gotinyEncode%s(e *gotinyEncoder, v *%s) {\n%s}\n", name, typ, g.encUnderlying(t, "(*v)", typ))
+		g.tmps = 0
+		fmt.Fprintf(&body, "\nfunc gotinyDecode%s(d *gotinyDecoder, v *%s) {\n%s}\n", name, typ, g.decUnderlying(t, "(*v)", typ))
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gotinygen. DO NOT EDIT.\n\npackage " + g.pkg.Name() + "\n")
+	writeImports(&buf, g.imports)
+	buf.Write(body.Bytes())
+	buf.WriteString(runtimeSource)
+	return format.Source(buf.Bytes())
+}
+
+// implementsOther reports whether target has serializer methods other than GotinyEncode and GotinyDecode
+func (g *generator) implementsOther(t *types.Named) bool {
+	pt := types.NewPointer(t)
+	return types.Implements(pt, gotinySerializer) || types.Implements(pt, binarySerializer) || types.Implements(pt, gobSerializer)
+}
+
+// writeImports writes import declaration of packages with names by path
+func writeImports(buf *bytes.Buffer, imports map[string]string) {
+	if len(imports) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	// standard packages go first in their own group
+	std := func(path string) bool { return !strings.Contains(strings.Split(path, "/")[0], ".") }
+	sort.Slice(paths, func(i, j int) bool {
+		if std(paths[i]) != std(paths[j]) {
+			return std(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	buf.WriteString("\nimport (\n")
+	for i, path := range paths {
+		if i > 0 && std(paths[i-1]) != std(path) {
+			buf.WriteString("\n")
+		}
+		name := imports[path]
+		if path == name || strings.HasSuffix(path, "/"+name) {
+			name = ""
+		}
+		fmt.Fprintf(buf, "%s %q\n", name, path)
+	}
+	buf.WriteString(")\n")
+}
+
+// helper returns name suffix of helper functions of named type t and queues them for writing
+func (g *generator) helper(t *types.Named) string {
+	if name, ok := g.helpers[t]; ok {
+		return name
+	}
+	name := t.Obj().Name()
+	if t.Obj().Pkg() != g.pkg {
+		name = t.Obj().Pkg().Name() + "_" + name
+	}
+	used := map[string]bool{}
+	for _, n := range g.helpers {
+		used[n] = true
+	}
+	for base, i := name, 2; used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.helpers[t] = name
+	g.queue = append(g.queue, t)
+	return name
+}
+
+// deref returns pointer x is dereference of, if it is, selectors and method calls need no explicit dereference
+func deref(x string) string {
+	if !strings.HasPrefix(x, "(*") {
+		return x
+	}
+	depth := 0
+	for i, c := range x {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 && i != len(x)-1 {
+				return x
+			}
+		}
+	}
+	return x[2 : len(x)-1]
+}
+
+// unparen returns x without parentheses of dereference for use as standalone operand
+func unparen(x string) string {
+	if deref(x) != x {
+		return x[1 : len(x)-1]
+	}
+	return x
+}
+
+// addr returns address of x
+func addr(x string) string {
+	if p := deref(x); p != x {
+		return p
+	}
+	return "&" + x
+}
+
+// field returns selector of field of struct x
+func field(x, name string) string {
+	return deref(x) + "." + name
+}
+
+// enc returns code encoding addressable expression x of type t
+func (g *generator) enc(t types.Type, x, path string) string {
+	if isPredeclared(t) {
+		return g.encUnderlying(t, x, path)
+	}
+	switch g.serializerOf(t) {
+	case gotinyBoolMethods:
+		return "e.buf = " + deref(x) + ".GotinyEncodeBools(e.buf, &e.boolPos, &e.boolBit)\n"
+	case gotinyMethods:
+		return "e.buf = " + deref(x) + ".GotinyEncode(e.buf)\n"
+	case binaryMethods:
+		return "{\nb, err := " + deref(x) + ".MarshalBinary()\nif err != nil {\npanic(err)\n}\ne.length(len(b))\ne.buf = append(e.buf, b...)\n}\n"
+	case gobMethods:
+		return "{\nb, err := " + deref(x) + ".GobEncode()\nif err != nil {\npanic(err)\n}\ne.length(len(b))\ne.buf = append(e.buf, b...)\n}\n"
+	}
+	if named, ok := t.(*types.Named); ok {
+		return "gotinyEncode" + g.helper(named) + "(e, " + addr(x) + ")\n"
+	}
+	return g.encUnderlying(t, x, path)
+}
+
+// dec returns code decoding to addressable expression x of type t
+func (g *generator) dec(t types.Type, x, path string) string {
+	if isPredeclared(t) {
+		return g.decUnderlying(t, x, path)
+	}
+	switch g.serializerOf(t) {
+	case gotinyBoolMethods:
+		return "d.index += " + deref(x) + ".GotinyDecodeBools(d.buf[d.index:], &d.boolPos, &d.boolBit)\n"
+	case gotinyMethods:
+		return "d.index += " + deref(x) + ".GotinyDecode(d.buf[d.index:])\n"
+	case binaryMethods:
+		return "{\nl := d.length()\nstart := d.index\nd.index += l\nif err := " + deref(x) + ".UnmarshalBinary(d.buf[start:d.index]); err != nil {\npanic(err)\n}\n}\n"
+	case gobMethods:
+		return "{\nl := d.length()\nstart := d.index\nd.index += l\nif err := " + deref(x) + ".GobDecode(d.buf[start:d.index]); err != nil {\npanic(err)\n}\n}\n"
+	}
+	if named, ok := t.(*types.Named); ok {
+		return "gotinyDecode" + g.helper(named) + "(d, " + addr(x) + ")\n"
+	}
+	return g.decUnderlying(t, x, path)
+}
+
+func (g *generator) encUnderlying(t types.Type, x, path string) string {
+	un := unparen(x)
+	if isTime(t) {
+		return "e.uint64(uint64(" + deref(x) + ".UnixNano()))\n"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return g.encBasic(u, g.typ(t), x, path)
+	case *types.Struct:
+		var code strings.Builder
+		g.fields(u, path, func(f *types.Var) {
+			code.WriteString(g.enc(f.Type(), field(x, f.Name()), path+"."+f.Name()))
+		})
+		return code.String()
+	case *types.Array:
+		return g.encLoop(u.Elem(), x, path)
+	case *types.Slice:
+		if types.Identical(u.Elem(), types.Typ[types.Byte]) {
+			return "e.bytes(" + convTo("[]byte", g.typ(t), un) + ")\n"
+		}
+		return "e.bool(" + un + " != nil)\nif " + un + " != nil {\ne.length(len(" + un + "))\n" + g.encLoop(u.Elem(), x, path) + "}\n"
+	case *types.Pointer:
+		return "e.bool(" + un + " != nil)\nif " + un + " != nil {\n" + g.enc(u.Elem(), "(*"+un+")", path+"*") + "}\n"
+	case *types.Map:
+		k, v := g.tmp("k"), g.tmp("v")
+		kCode, vCode := g.enc(u.Key(), k, path+"{key}"), g.enc(u.Elem(), v, path+"{value}")
+		if kCode == "" {
+			k = "_"
+		}
+		if vCode == "" {
+			v = "_"
+		}
+		loop := ""
+		switch {
+		case vCode != "":
+			loop = "for " + k + ", " + v + " := range " + un + " {\n" + kCode + vCode + "}\n"
+		case kCode != "":
+			loop = "for " + k + " := range " + un + " {\n" + kCode + "}\n"
+		}
+		return "e.bool(" + un + " != nil)\nif " + un + " != nil {\ne.length(len(" + un + "))\n" + loop + "}\n"
+	}
+	g.fail(path, "type %s is not supported", g.typ(t))
+	return ""
+}
+
+func (g *generator) encLoop(elem types.Type, x, path string) string {
+	un := unparen(x)
+	i := g.tmp("i")
+	body := g.enc(elem, x+"["+i+"]", path+"[]")
+	if body == "" {
+		return ""
+	}
+	return "for " + i + " := range " + un + " {\n" + body + "}\n"
+}
+
+func (g *generator) encBasic(t *types.Basic, typ, x, path string) string {
+	un := unparen(x)
+	math, bits := "", ""
+	if t.Info()&(types.IsFloat|types.IsComplex) != 0 {
+		math = g.use("math", "math")
+	}
+	if t.Info()&types.IsFloat != 0 {
+		bits = g.use("math/bits", "bits")
+	}
+	switch t.Kind() {
+	case types.Bool:
+		return "e.bool(" + convTo("bool", typ, un) + ")\n"
+	case types.Int, types.Int64:
+		return "e.int64(" + convTo("int64", typ, un) + ")\n"
+	case types.Int32:
+		return "e.int32(" + convTo("int32", typ, un) + ")\n"
+	case types.Int16:
+		return "e.int16(" + convTo("int16", typ, un) + ")\n"
+	case types.Int8, types.Uint8:
+		return "e.buf = append(e.buf, " + convTo("byte", typ, un) + ")\n"
+	case types.Uint, types.Uint64, types.Uintptr:
+		return "e.uint64(" + convTo("uint64", typ, un) + ")\n"
+	case types.Uint32:
+		return "e.uint32(" + convTo("uint32", typ, un) + ")\n"
+	case types.Uint16:
+		return "e.uint16(" + convTo("uint16", typ, un) + ")\n"
+	case types.Float32:
+		return "e.uint32(" + bits + ".ReverseBytes32(" + math + ".Float32bits(" + convTo("float32", typ, un) + ")))\n"
+	case types.Float64:
+		return "e.uint64(" + bits + ".ReverseBytes64(" + math + ".Float64bits(" + convTo("float64", typ, un) + ")))\n"
+	case types.Complex64:
+		// memory of complex64 is written as uint64, real part is in lower half on little endian machines
+		return "e.uint64(uint64(" + math + ".Float32bits(real(" + un + "))) | uint64(" + math + ".Float32bits(imag(" + un + ")))<<32)\n"
+	case types.Complex128:
+		return "e.uint64(" + math + ".Float64bits(real(" + un + ")))\ne.uint64(" + math + ".Float64bits(imag(" + un + ")))\n"
+	case types.String:
+		return "e.string(" + convTo("string", typ, un) + ")\n"
+	}
+	g.fail(path, "type %s is not supported", t)
+	return ""
+}
+
+func (g *generator) decUnderlying(t types.Type, x, path string) string {
+	un := unparen(x)
+	if isTime(t) {
+		return un + " = " + g.use("time", "time") + ".Unix(0, int64(d.uint64()))\n"
+	}
+	typ := g.typ(t)
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return g.decBasic(u, typ, x, path)
+	case *types.Struct:
+		var code strings.Builder
+		g.fields(u, path, func(f *types.Var) {
+			code.WriteString(g.dec(f.Type(), field(x, f.Name()), path+"."+f.Name()))
+		})
+		return code.String()
+	case *types.Array:
+		return g.decLoop(u.Elem(), x, path)
+	case *types.Slice:
+		if types.Identical(t, byteSlice) {
+			// as gotiny does, []byte refers to decoded buffer
+			return un + " = d.bytes()\n"
+		}
+		l := g.tmp("l")
+		fill := g.decLoop(u.Elem(), x, path)
+		if types.Identical(u.Elem(), types.Typ[types.Byte]) {
+			fill = "copy(" + un + ", d.buf[d.index:d.index+" + l + "])\nd.index += " + l + "\n"
+		}
+		return "if d.bool() {\n" + l + " := d.length()\nif " + un + " == nil || cap(" + un + ") < " + l + " {\n" +
+			un + " = make(" + typ + ", " + l + ")\n} else {\n" + un + " = " + x + "[:" + l + "]\n}\n" + fill +
+			"} else if " + un + " != nil {\n" + un + " = nil\n}\n"
+	case *types.Pointer:
+		return "if d.bool() {\nif " + un + " == nil {\n" + un + " = new(" + g.typ(u.Elem()) + ")\n}\n" + g.dec(u.Elem(), "(*"+un+")", path+"*") +
+			"} else if " + un + " != nil {\n" + un + " = nil\n}\n"
+	case *types.Map:
+		l, i, k, v := g.tmp("l"), g.tmp("i"), g.tmp("k"), g.tmp("v")
+		return "if d.bool() {\n" + l + " := d.length()\nif " + un + " == nil {\n" + un + " = make(" + typ + ", " + l + ")\n}\n" +
+			"for " + i + " := 0; " + i + " < " + l + "; " + i + "++ {\nvar " + k + " " + g.typ(u.Key()) + "\nvar " + v + " " + g.typ(u.Elem()) + "\n" +
+			g.dec(u.Key(), k, path+"{key}") + g.dec(u.Elem(), v, path+"{value}") + x + "[" + k + "] = " + v + "\n}\n" +
+			"} else if " + un + " != nil {\n" + un + " = nil\n}\n"
+	}
+	g.fail(path, "type %s is not supported", typ)
+	return ""
+}
+
+func (g *generator) decLoop(elem types.Type, x, path string) string {
+	un := unparen(x)
+	i := g.tmp("i")
+	body := g.dec(elem, x+"["+i+"]", path+"[]")
+	if body == "" {
+		return ""
+	}
+	return "for " + i + " := range " + un + " {\n" + body + "}\n"
+}
+
+func (g *generator) decBasic(t *types.Basic, typ, x, path string) string {
+	un := unparen(x)
+	math, bits := "", ""
+	if t.Info()&(types.IsFloat|types.IsComplex) != 0 {
+		math = g.use("math", "math")
+	}
+	if t.Info()&types.IsFloat != 0 {
+		bits = g.use("math/bits", "bits")
+	}
+	set := func(from, v string) string { return un + " = " + convTo(typ, from, v) + "\n" }
+	switch t.Kind() {
+	case types.Bool:
+		return set("bool", "d.bool()")
+	case types.Int, types.Int64:
+		return set("int64", "d.int64()")
+	case types.Int32:
+		return set("int32", "d.int32()")
+	case types.Int16:
+		return set("int16", "d.int16()")
+	case types.Int8, types.Uint8:
+		return set("byte", "d.byte()")
+	case types.Uint, types.Uint64, types.Uintptr:
+		return set("uint64", "d.uint64()")
+	case types.Uint32:
+		return set("uint32", "d.uint32()")
+	case types.Uint16:
+		return set("uint16", "d.uint16()")
+	case types.Float32:
+		return set("float32", math+".Float32frombits("+bits+".ReverseBytes32(d.uint32()))")
+	case types.Float64:
+		return set("float64", math+".Float64frombits("+bits+".ReverseBytes64(d.uint64()))")
+	case types.Complex64:
+		u := g.tmp("u")
+		return "{\n" + u + " := d.uint64()\n" + set("complex64", "complex("+math+".Float32frombits(uint32("+u+")), "+math+".Float32frombits(uint32("+u+">>32)))") + "}\n"
+	case types.Complex128:
+		re := g.tmp("re")
+		return "{\n" + re + " := " + math + ".Float64frombits(d.uint64())\n" + set("complex128", "complex("+re+", "+math+".Float64frombits(d.uint64()))") + "}\n"
+	case types.String:
+		return set("string", "d.string()")
+	}
+	g.fail(path, "type %s is not supported", t)
+	return ""
+}
+
+// convTo converts expression x of type from to type typ if they differ
+func convTo(typ, from, x string) string {
+	if typ == from || typ == "uint8" && from == "byte" || typ == "byte" && from == "uint8" {
+		return x
+	}
+	return typ + "(" + x + ")"
+}
+
+// fields calls f for every encoded field of struct, fields are encoded in order of declaration
+// except ones tagged with `gotiny:"-"`
+func (g *generator) fields(s *types.Struct, path string, f func(*types.Var)) {
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		tag, _ := reflect.StructTag(s.Tag(i)).Lookup("gotiny")
+		if strings.TrimSpace(tag) == "-" {
+			continue
+		}
+		switch {
+		case strings.TrimSpace(tag) == "packed":
+			g.fail(path, "packed field %s is not supported", v.Name())
+		case v.Name() == "_":
+			g.fail(path, "blank fields are not supported")
+		case !v.Exported() && v.Pkg() != g.pkg:
+			g.fail(path, "unexported field %s of type from package %s is not accessible", v.Name(), v.Pkg().Path())
+		default:
+			f(v)
+		}
+	}
+}
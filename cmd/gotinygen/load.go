@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+const annotation = "//gotiny:generate"
+
+type pkgInfo struct {
+	types     *types.Package
+	annotated []string // names of annotated types in order of declaration
+}
+
+// loadPackage parses and type checks package in dir, files from exclude are skipped,
+// so previously generated code doesn't break type checking after types were changed
+func loadPackage(dir string, exclude map[string]bool) (*pkgInfo, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	info := &pkgInfo{}
+	for _, name := range bp.GoFiles {
+		if exclude[name] {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		info.annotated = append(info.annotated, annotatedTypes(f)...)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if info.types, err = conf.Check(bp.ImportPath, fset, files, nil); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func annotatedTypes(f *ast.File) (names []string) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.TypeSpec)
+			doc := spec.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			if hasAnnotation(doc) {
+				names = append(names, spec.Name.Name)
+			}
+		}
+	}
+	return
+}
+
+func hasAnnotation(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+// targets returns named types methods are generated for
+func (p *pkgInfo) targets(names []string) ([]*types.Named, error) {
+	if names == nil {
+		names = p.annotated
+	}
+	var targets []*types.Named
+	for _, name := range names {
+		obj, ok := p.types.Scope().Lookup(strings.TrimSpace(name)).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not found in package %s", name, p.types.Path())
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || obj.IsAlias() {
+			return nil, fmt.Errorf("%s is not a defined type", name)
+		}
+		if _, ok := named.Underlying().(*types.Pointer); ok {
+			return nil, fmt.Errorf("%s is a pointer type, methods can't be declared on it", name)
+		}
+		if _, ok := named.Underlying().(*types.Interface); ok {
+			return nil, fmt.Errorf("%s is an interface type, methods can't be declared on it", name)
+		}
+		if named.TypeParams().Len() > 0 {
+			return nil, fmt.Errorf("%s is a generic type, it is not supported", name)
+		}
+		targets = append(targets, named)
+	}
+	return targets, nil
+}
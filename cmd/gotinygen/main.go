@@ -0,0 +1,105 @@
+// Command gotinygen generates GotinyEncode and GotinyDecode methods, so types
+// implement gotiny.GoTinySerializer without reflection and unsafe.
+//
+// Usage:
+//
+//	//go:generate gotinygen [-type T1,T2] [-output gotiny_gen.go] [-test]
+//
+// Without -type methods are generated for types annotated with
+//
+//	//gotiny:generate
+//
+// comment in package in current directory (or directory given as argument).
+// Generated code writes exactly the same bytes as reflective engines of gotiny.Coder,
+// so values encoded by generated and reflective code can be decoded by each other.
+// With -test also test is generated, it encodes random values of every type
+// with generated methods and with gotiny.Marshal and compares results.
+//
+// Interface, chan, func and unsafe.Pointer types are not supported, as well as
+// unexported fields of structs from other packages without serializer methods.
+// All types of package should be generated by one invocation, because generated
+// file contains helpers shared by all of them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma separated list of type names, by default annotated types are used")
+	output    = flag.String("output", "gotiny_gen.go", "output file name")
+	withTest  = flag.Bool("test", false, "generate test checking equivalence with reflective engines")
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	dir := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	files, err := generate(dir, *output, names, *withTest)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gotinygen:", err)
+		os.Exit(1)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), src, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "gotinygen:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gotinygen [flags] [directory]")
+	flag.PrintDefaults()
+}
+
+// testFileName returns name of generated test file for output file name
+func testFileName(output string) string {
+	return strings.TrimSuffix(output, ".go") + "_test.go"
+}
+
+// generate returns generated files of package in dir by their names
+func generate(dir, output string, names []string, withTest bool) (map[string][]byte, error) {
+	pkg, err := loadPackage(dir, map[string]bool{output: true, testFileName(output): true})
+	if err != nil {
+		return nil, err
+	}
+	targets, err := pkg.targets(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no types to generate in %s, use -type or //gotiny:generate annotation", dir)
+	}
+
+	g := newGenerator(pkg.types, targets)
+	src, err := g.file()
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{output: src}
+	if withTest {
+		if files[testFileName(output)], err = g.testFile(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
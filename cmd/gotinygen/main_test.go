@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// generated example is checked in, its own test compares generated code with reflective engines
+func TestGenerateExample(t *testing.T) {
+	dir := filepath.Join("..", "..", "example", "generated")
+	files, err := generate(dir, "gotiny_gen.go", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected code and test files, got %d files", len(files))
+	}
+	for name, src := range files {
+		exp, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, exp) {
+			t.Errorf("%s is out of date, run go generate in %s", name, dir)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotinygen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := `package p
+
+type (
+	ok struct{ A int }
+	withInterface struct{ R interface{ Read() } }
+	withChan struct{ C []chan int }
+	withPacked struct {
+		F []float64 ` + "`gotiny:\"packed\"`" + `
+	}
+	custom struct{ A int }
+)
+
+func (c *custom) MarshalBinary() ([]byte, error) { return nil, nil }
+func (c *custom) UnmarshalBinary([]byte) error  { return nil }
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := generate(dir, "gotiny_gen.go", []string{"ok"}, false); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, c := range []struct {
+		typ, err string
+	}{
+		{"withInterface", "withInterface.R: type interface{Read()} is not supported"},
+		{"withChan", "withChan.C[]: type chan int is not supported"},
+		{"withPacked", "withPacked: packed field F is not supported"},
+		{"custom", "custom already has serializer methods"},
+		{"missing", "type missing is not found"},
+	} {
+		_, err := generate(dir, "gotiny_gen.go", []string{c.typ}, false)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expected error %q, got %v", c.typ, c.err, err)
+		}
+	}
+	if _, err := generate(dir, "gotiny_gen.go", nil, false); err == nil {
+		t.Error("expected error for package without annotated types")
+	}
+}
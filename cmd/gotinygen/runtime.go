@@ -0,0 +1,244 @@
+package main
+
+// runtimeSource is appended to every generated file,
+// it is a copy of primitives of gotiny Encoder and Decoder, so wire format is the same
+const runtimeSource = `
+type gotinyEncoder struct {
+	buf     []byte
+	boolPos int
+	boolBit byte
+}
+
+func (e *gotinyEncoder) bool(v bool) {
+	if e.boolBit == 0 {
+		e.boolPos = len(e.buf)
+		e.buf = append(e.buf, 0)
+		e.boolBit = 1
+	}
+	if v {
+		e.buf[e.boolPos] |= e.boolBit
+	}
+	e.boolBit <<= 1
+}
+
+func (e *gotinyEncoder) uint64(v uint64) {
+	switch {
+	case v < 1<<7-1:
+		e.buf = append(e.buf, byte(v))
+	case v < 1<<14-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7))
+	case v < 1<<21-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14))
+	case v < 1<<28-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21))
+	case v < 1<<35-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28))
+	case v < 1<<42-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35))
+	case v < 1<<49-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35)|0x80, byte(v>>42))
+	case v < 1<<56-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35)|0x80, byte(v>>42)|0x80, byte(v>>49))
+	default:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35)|0x80, byte(v>>42)|0x80, byte(v>>49)|0x80, byte(v>>56))
+	}
+}
+
+func (e *gotinyEncoder) uint32(v uint32) {
+	switch {
+	case v < 1<<7-1:
+		e.buf = append(e.buf, byte(v))
+	case v < 1<<14-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7))
+	case v < 1<<21-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14))
+	case v < 1<<28-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21))
+	default:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28))
+	}
+}
+
+func (e *gotinyEncoder) uint16(v uint16) {
+	if v < 1<<7-1 {
+		e.buf = append(e.buf, byte(v))
+	} else if v < 1<<14-1 {
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7))
+	} else {
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14))
+	}
+}
+
+func (e *gotinyEncoder) int64(v int64)   { e.uint64(uint64((v << 1) ^ (v >> 63))) }
+func (e *gotinyEncoder) int32(v int32)   { e.uint32(uint32((v << 1) ^ (v >> 31))) }
+func (e *gotinyEncoder) int16(v int16)   { e.uint16(uint16((v << 1) ^ (v >> 15))) }
+func (e *gotinyEncoder) length(l int)    { e.uint32(uint32(l)) }
+func (e *gotinyEncoder) string(s string) { e.uint32(uint32(len(s))); e.buf = append(e.buf, s...) }
+
+func (e *gotinyEncoder) bytes(b []byte) {
+	e.bool(b != nil)
+	if b != nil {
+		e.uint32(uint32(len(b)))
+		e.buf = append(e.buf, b...)
+	}
+}
+
+type gotinyDecoder struct {
+	buf     []byte
+	index   int
+	boolPos byte
+	boolBit byte
+}
+
+func (d *gotinyDecoder) bool() (b bool) {
+	if d.boolBit == 0 {
+		d.boolBit = 1
+		d.boolPos = d.buf[d.index]
+		d.index++
+	}
+	b = d.boolPos&d.boolBit != 0
+	d.boolBit <<= 1
+	return
+}
+
+func (d *gotinyDecoder) uint64() uint64 {
+	buf, i := d.buf, d.index
+	x := uint64(buf[i])
+	if x < 0x80 {
+		d.index++
+		return x
+	}
+	x1 := buf[i+1]
+	x += uint64(x1) << 7
+	if x1 < 0x80 {
+		d.index += 2
+		return x - 1<<7
+	}
+	x2 := buf[i+2]
+	x += uint64(x2) << 14
+	if x2 < 0x80 {
+		d.index += 3
+		return x - (1<<7 + 1<<14)
+	}
+	x3 := buf[i+3]
+	x += uint64(x3) << 21
+	if x3 < 0x80 {
+		d.index += 4
+		return x - (1<<7 + 1<<14 + 1<<21)
+	}
+	x4 := buf[i+4]
+	x += uint64(x4) << 28
+	if x4 < 0x80 {
+		d.index += 5
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28)
+	}
+	x5 := buf[i+5]
+	x += uint64(x5) << 35
+	if x5 < 0x80 {
+		d.index += 6
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35)
+	}
+	x6 := buf[i+6]
+	x += uint64(x6) << 42
+	if x6 < 0x80 {
+		d.index += 7
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35 + 1<<42)
+	}
+	x7 := buf[i+7]
+	x += uint64(x7) << 49
+	if x7 < 0x80 {
+		d.index += 8
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35 + 1<<42 + 1<<49)
+	}
+	d.index += 9
+	return x + uint64(buf[i+8])<<56 - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35 + 1<<42 + 1<<49 + 1<<56)
+}
+
+func (d *gotinyDecoder) uint32() uint32 {
+	buf, i := d.buf, d.index
+	x := uint32(buf[i])
+	if x < 0x80 {
+		d.index++
+		return x
+	}
+	x1 := buf[i+1]
+	x += uint32(x1) << 7
+	if x1 < 0x80 {
+		d.index += 2
+		return x - 1<<7
+	}
+	x2 := buf[i+2]
+	x += uint32(x2) << 14
+	if x2 < 0x80 {
+		d.index += 3
+		return x - (1<<7 + 1<<14)
+	}
+	x3 := buf[i+3]
+	x += uint32(x3) << 21
+	if x3 < 0x80 {
+		d.index += 4
+		return x - (1<<7 + 1<<14 + 1<<21)
+	}
+	x4 := buf[i+4]
+	x += uint32(x4) << 28
+	d.index += 5
+	return x - (1<<7 + 1<<14 + 1<<21 + 1<<28)
+}
+
+func (d *gotinyDecoder) uint16() uint16 {
+	buf, i := d.buf, d.index
+	x := uint16(buf[i])
+	if x < 0x80 {
+		d.index++
+		return x
+	}
+	x1 := buf[i+1]
+	x += uint16(x1) << 7
+	if x1 < 0x80 {
+		d.index += 2
+		return x - 1<<7
+	}
+	d.index += 3
+	return x + uint16(buf[i+2])<<14 - (1<<7 + 1<<14)
+}
+
+func (d *gotinyDecoder) int64() int64 {
+	v := int64(d.uint64())
+	return (-(v & 1)) ^ (v>>1)&0x7FFFFFFFFFFFFFFF
+}
+
+func (d *gotinyDecoder) int32() int32 {
+	v := int32(d.uint32())
+	return (-(v & 1)) ^ (v>>1)&0x7FFFFFFF
+}
+
+func (d *gotinyDecoder) int16() int16 {
+	v := int16(d.uint16())
+	return (-(v & 1)) ^ (v>>1)&0x7FFF
+}
+
+func (d *gotinyDecoder) byte() byte {
+	b := d.buf[d.index]
+	d.index++
+	return b
+}
+
+func (d *gotinyDecoder) length() int { return int(d.uint32()) }
+
+func (d *gotinyDecoder) string() string {
+	l := int(d.uint32())
+	s := string(d.buf[d.index : d.index+l])
+	d.index += l
+	return s
+}
+
+func (d *gotinyDecoder) bytes() []byte {
+	if !d.bool() {
+		return nil
+	}
+	l := int(d.uint32())
+	b := d.buf[d.index : d.index+l]
+	d.index += l
+	return b
+}
+`
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// testFile returns test which encodes random values of targets with generated methods
+// and with reflective engines of gotiny and checks results are the same.
+// Reflective engines are used for mirror types without methods, every type of package
+// generated code encodes is re-declared in them, so nested values aren't encoded by methods either.
+// Values are also checked as field following bool, generated methods share its byte of bools.
+func (g *generator) testFile() ([]byte, error) {
+	var body bytes.Buffer
+	body.WriteString("\nfunc TestGotinyGenerated(t *testing.T) {\nr := rand.New(rand.NewSource(1))\nfor i := 0; i < 200; i++ {\n")
+	for _, t := range g.targets {
+		name, plain := t.Obj().Name(), g.plainName(t)
+		wrapped, plainWrapped := "struct {\nA bool\nV "+name+"\n}", "struct {\nA bool\nV "+plain+"\n}"
+		fmt.Fprintf(&body, "{\nv := new(%s)\nif i > 0 {\ngotinyRandom(r, reflect.ValueOf(v).Elem(), 0)\n}\n", name)
+		fmt.Fprintf(&body, "gotinyCheck(t, %q, v, (*%s)(unsafe.Pointer(v)), new(%[1]s), new(%[2]s))\n", name, plain)
+		fmt.Fprintf(&body, "w := &%s{A: i%%2 == 0, V: *v}\n", wrapped)
+		fmt.Fprintf(&body, "gotinyCheckWrapped(t, %q, w, (*%s)(unsafe.Pointer(w)), new(%s), new(%[2]s))\n}\n", "wrapped "+name, plainWrapped, wrapped)
+	}
+	body.WriteString("}\n}\n")
+
+	imports := map[string]string{}
+	for _, path := range []string{"bytes", "math/rand", "reflect", "testing", "time", "unsafe", "github.com/niubaoshu/gotiny"} {
+		imports[path] = path[strings.LastIndex(path, "/")+1:]
+	}
+	var decls strings.Builder
+	for _, t := range g.plainTypes() {
+		fmt.Fprintf(&decls, "\ntype %s %s\n", g.plainName(t), g.plain(t.Underlying(), imports))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gotinygen. DO NOT EDIT.\n\npackage %s\n", g.pkg.Name())
+	writeImports(&buf, imports)
+	buf.Write(body.Bytes())
+	buf.WriteString(decls.String())
+	buf.WriteString(testSource)
+	return format.Source(buf.Bytes())
+}
+
+// plainTypes returns targets and types of package encoded by helpers, they are re-declared as mirror types
+func (g *generator) plainTypes() []*types.Named {
+	var helpers []*types.Named
+	for t := range g.helpers {
+		if t.Obj().Pkg() == g.pkg {
+			helpers = append(helpers, t)
+		}
+	}
+	sort.Slice(helpers, func(i, j int) bool { return helpers[i].Obj().Name() < helpers[j].Obj().Name() })
+	return append(append([]*types.Named{}, g.targets...), helpers...)
+}
+
+func (g *generator) plainName(t *types.Named) string {
+	return "gotinyPlain" + t.Obj().Name()
+}
+
+// plain returns mirror of type t without methods, types of other packages and types
+// with serializer methods of their own are kept, packages they are from are added to imports
+func (g *generator) plain(t types.Type, imports map[string]string) string {
+	switch t := t.(type) {
+	case *types.Named:
+		if _, ok := g.helpers[t]; g.isTarget[t] || ok && t.Obj().Pkg() == g.pkg {
+			return g.plainName(t)
+		}
+	case *types.Pointer:
+		return "*" + g.plain(t.Elem(), imports)
+	case *types.Slice:
+		return "[]" + g.plain(t.Elem(), imports)
+	case *types.Array:
+		return "[" + strconv.FormatInt(t.Len(), 10) + "]" + g.plain(t.Elem(), imports)
+	case *types.Map:
+		return "map[" + g.plain(t.Key(), imports) + "]" + g.plain(t.Elem(), imports)
+	case *types.Struct:
+		var s strings.Builder
+		s.WriteString("struct {\n")
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			if !f.Embedded() {
+				s.WriteString(f.Name() + " ")
+			}
+			s.WriteString(g.plain(f.Type(), imports))
+			if tag := t.Tag(i); tag != "" {
+				s.WriteString(" " + strconv.Quote(tag))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString("}")
+		return s.String()
+	}
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		imports[p.Path()] = g.use(p.Path(), p.Name())
+		return imports[p.Path()]
+	})
+}
+
+const testSource = `
+func gotinyCheck(t *testing.T, name string, v gotiny.GoTinySerializer, plain interface{}, ret gotiny.GoTinySerializer, plainRet interface{}) {
+	t.Helper()
+	got, exp := v.GotinyEncode(nil), gotiny.Marshal(plain)
+	if !bytes.Equal(got, exp) {
+		t.Fatalf("%s: generated encoder wrote\n%v\nreflective encoder wrote\n%v", name, got, exp)
+	}
+	if via := gotiny.Marshal(v); !bytes.Equal(via, got) {
+		t.Fatalf("%s: gotiny wrote with generated methods\n%v\nexpected\n%v", name, via, got)
+	}
+	if n := ret.GotinyDecode(got); n != len(got) {
+		t.Fatalf("%s: generated decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := ret.GotinyEncode(nil); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by generated decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+	if n := gotiny.Unmarshal(got, plainRet); n != len(got) {
+		t.Fatalf("%s: reflective decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := gotiny.Marshal(plainRet); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by reflective decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+}
+
+// gotinyCheckWrapped checks value which is encoded by gotiny with generated methods of its field
+func gotinyCheckWrapped(t *testing.T, name string, v, plain, ret, plainRet interface{}) {
+	t.Helper()
+	got, exp := gotiny.Marshal(v), gotiny.Marshal(plain)
+	if !bytes.Equal(got, exp) {
+		t.Fatalf("%s: generated encoder wrote\n%v\nreflective encoder wrote\n%v", name, got, exp)
+	}
+	if n := gotiny.Unmarshal(got, ret); n != len(got) {
+		t.Fatalf("%s: generated decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := gotiny.Marshal(ret); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by generated decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+	if n := gotiny.Unmarshal(got, plainRet); n != len(got) {
+		t.Fatalf("%s: reflective decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := gotiny.Marshal(plainRet); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by reflective decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+}
+
+// gotinyRandom fills v with random value, maps get at most one element to have determined order
+func gotinyRandom(r *rand.Rand, v reflect.Value, depth int) {
+	if !v.CanSet() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(time.Unix(0, r.Int63())))
+		return
+	}
+	deep := depth > 3
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63() >> uint(r.Intn(63)) * int64(1-2*r.Intn(2)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(r.Uint64() >> uint(r.Intn(64)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.NormFloat64() * 1e6)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(complex(r.NormFloat64(), r.NormFloat64()))
+	case reflect.String:
+		b := make([]byte, r.Intn(16))
+		r.Read(b)
+		v.SetString(string(b))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			gotinyRandom(r, v.Index(i), depth+1)
+		}
+	case reflect.Slice:
+		if r.Intn(4) == 0 {
+			return
+		}
+		n := r.Intn(4)
+		if deep {
+			n = 0
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			gotinyRandom(r, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if r.Intn(4) == 0 {
+			return
+		}
+		v.Set(reflect.MakeMap(v.Type()))
+		if !deep && r.Intn(2) == 0 {
+			key, val := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			gotinyRandom(r, key, depth+1)
+			gotinyRandom(r, val, depth+1)
+			v.SetMapIndex(key, val)
+		}
+	case reflect.Ptr:
+		if deep || r.Intn(3) == 0 {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		gotinyRandom(r, v.Elem(), depth+1)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			gotinyRandom(r, v.Field(i), depth+1)
+		}
+	}
+}
+`
@@ -2,6 +2,7 @@ package gotiny
 
 import (
 	"reflect"
+	"strconv"
 	"sync"
 	"time"
 	"unsafe"
@@ -35,34 +36,95 @@ const (
 	typeComplex64
 	typeComplex128
 	typeCustom // custom serialiser
+	typeString
 )
 
+var gotinyTypeNames = [...]string{
+	typeIgnore:     "ignore",
+	typeStruct:     "struct",
+	typeSlice:      "slice",
+	typeArray:      "array",
+	typeMap:        "map",
+	typeBool:       "bool",
+	typeInt:        "int",
+	typeInt8:       "int8",
+	typeInt16:      "int16",
+	typeInt32:      "int32",
+	typeInt64:      "int64",
+	typeUint:       "uint",
+	typeUint8:      "uint8",
+	typeUint16:     "uint16",
+	typeUint32:     "uint32",
+	typeUint64:     "uint64",
+	typeFloat32:    "float32",
+	typeFloat64:    "float64",
+	typeBytes:      "bytes",
+	typeTime:       "time",
+	typeInterface:  "interface",
+	typePointer:    "pointer",
+	typeComplex64:  "complex64",
+	typeComplex128: "complex128",
+	typeCustom:     "custom",
+	typeString:     "string",
+}
+
+func (t gotinyType) String() string {
+	if int(t) < len(gotinyTypeNames) {
+		return gotinyTypeNames[t]
+	}
+	return "type(" + strconv.Itoa(int(t)) + ")"
+}
+
 var (
 	rt2Node = map[reflect.Type]Scheme{
-		reflect.TypeOf((*bool)(nil)).Elem():           Scheme{encodeEngine: encBool, decodeEngine: decBool, Type: typeBool},
-		reflect.TypeOf((*int)(nil)).Elem():            Scheme{encodeEngine: encInt, decodeEngine: decInt, Type: typeInt},
-		reflect.TypeOf((*int8)(nil)).Elem():           Scheme{encodeEngine: encInt8, decodeEngine: decInt8, Type: typeInt8},
-		reflect.TypeOf((*int32)(nil)).Elem():          Scheme{encodeEngine: encInt32, decodeEngine: decInt32, Type: typeInt32},
-		reflect.TypeOf((*int16)(nil)).Elem():          Scheme{encodeEngine: encInt16, decodeEngine: decInt16, Type: typeInt16},
-		reflect.TypeOf((*int64)(nil)).Elem():          Scheme{encodeEngine: encInt64, decodeEngine: decInt64, Type: typeInt64},
-		reflect.TypeOf((*uint)(nil)).Elem():           Scheme{encodeEngine: encUint, decodeEngine: decUint, Type: typeUint},
-		reflect.TypeOf((*uint8)(nil)).Elem():          Scheme{encodeEngine: encUint8, decodeEngine: decUint8, Type: typeUint8},
-		reflect.TypeOf((*uint16)(nil)).Elem():         Scheme{encodeEngine: encUint16, decodeEngine: decUint16, Type: typeUint16},
-		reflect.TypeOf((*uint32)(nil)).Elem():         Scheme{encodeEngine: encUint32, decodeEngine: decUint32, Type: typeUint32},
-		reflect.TypeOf((*uint64)(nil)).Elem():         Scheme{encodeEngine: encUint64, decodeEngine: decUint64, Type: typeUint64},
-		reflect.TypeOf((*uintptr)(nil)).Elem():        Scheme{encodeEngine: encUintptr, decodeEngine: decUintptr, Type: typeUint64}, // encoded as int
-		reflect.TypeOf((*unsafe.Pointer)(nil)).Elem(): Scheme{encodeEngine: encPointer, decodeEngine: decPointer, Type: typeUint64},
-		reflect.TypeOf((*float32)(nil)).Elem():        Scheme{encodeEngine: encFloat32, decodeEngine: decFloat32, Type: typeFloat32},
-		reflect.TypeOf((*float64)(nil)).Elem():        Scheme{encodeEngine: encFloat64, decodeEngine: decFloat64, Type: typeFloat64},
-		reflect.TypeOf((*complex64)(nil)).Elem():      Scheme{encodeEngine: encComplex64, decodeEngine: decComplex64, Type: typeComplex64},
-		reflect.TypeOf((*complex128)(nil)).Elem():     Scheme{encodeEngine: encComplex128, decodeEngine: decComplex128, Type: typeComplex128},
-		reflect.TypeOf((*[]byte)(nil)).Elem():         Scheme{encodeEngine: encBytes, decodeEngine: decBytes, Type: typeBytes},
-		reflect.TypeOf((*string)(nil)).Elem():         Scheme{encodeEngine: encString, decodeEngine: decString, Type: typeBytes},
-		reflect.TypeOf((*time.Time)(nil)).Elem():      Scheme{encodeEngine: encTime, decodeEngine: decTime, Type: typeUint64},
-		reflect.TypeOf((*struct{})(nil)).Elem():       Scheme{encodeEngine: encIgnore, decodeEngine: decIgnore, Type: typeIgnore},
-		reflect.TypeOf(nil):                           Scheme{encodeEngine: encIgnore, decodeEngine: decIgnore, Type: typeIgnore},
+		reflect.TypeOf((*bool)(nil)).Elem():           Scheme{encodeEngine: encBool, decodeEngine: decBool, sizeEngine: sizeBool, Type: typeBool},
+		reflect.TypeOf((*int)(nil)).Elem():            Scheme{encodeEngine: encInt, decodeEngine: decInt, sizeEngine: sizeInt, Type: typeInt},
+		reflect.TypeOf((*int8)(nil)).Elem():           Scheme{encodeEngine: encInt8, decodeEngine: decInt8, sizeEngine: sizeByte, Type: typeInt8},
+		reflect.TypeOf((*int32)(nil)).Elem():          Scheme{encodeEngine: encInt32, decodeEngine: decInt32, sizeEngine: sizeInt32, Type: typeInt32},
+		reflect.TypeOf((*int16)(nil)).Elem():          Scheme{encodeEngine: encInt16, decodeEngine: decInt16, sizeEngine: sizeInt16, Type: typeInt16},
+		reflect.TypeOf((*int64)(nil)).Elem():          Scheme{encodeEngine: encInt64, decodeEngine: decInt64, sizeEngine: sizeInt64, Type: typeInt64},
+		reflect.TypeOf((*uint)(nil)).Elem():           Scheme{encodeEngine: encUint, decodeEngine: decUint, sizeEngine: sizeUint, Type: typeUint},
+		reflect.TypeOf((*uint8)(nil)).Elem():          Scheme{encodeEngine: encUint8, decodeEngine: decUint8, sizeEngine: sizeByte, Type: typeUint8},
+		reflect.TypeOf((*uint16)(nil)).Elem():         Scheme{encodeEngine: encUint16, decodeEngine: decUint16, sizeEngine: sizeUint16, Type: typeUint16},
+		reflect.TypeOf((*uint32)(nil)).Elem():         Scheme{encodeEngine: encUint32, decodeEngine: decUint32, sizeEngine: sizeUint32, Type: typeUint32},
+		reflect.TypeOf((*uint64)(nil)).Elem():         Scheme{encodeEngine: encUint64, decodeEngine: decUint64, sizeEngine: sizeUint64, Type: typeUint64},
+		reflect.TypeOf((*uintptr)(nil)).Elem():        Scheme{encodeEngine: encUintptr, decodeEngine: decUintptr, sizeEngine: sizeUintptr, Type: typeUint64}, // encoded as int
+		reflect.TypeOf((*unsafe.Pointer)(nil)).Elem(): Scheme{encodeEngine: encPointer, decodeEngine: decPointer, sizeEngine: sizeUintptr, Type: typeUint64},
+		reflect.TypeOf((*float32)(nil)).Elem():        Scheme{encodeEngine: encFloat32, decodeEngine: decFloat32, sizeEngine: sizeFloat32, Type: typeFloat32},
+		reflect.TypeOf((*float64)(nil)).Elem():        Scheme{encodeEngine: encFloat64, decodeEngine: decFloat64, sizeEngine: sizeFloat64, Type: typeFloat64},
+		reflect.TypeOf((*complex64)(nil)).Elem():      Scheme{encodeEngine: encComplex64, decodeEngine: decComplex64, sizeEngine: sizeComplex64, Type: typeComplex64},
+		reflect.TypeOf((*complex128)(nil)).Elem():     Scheme{encodeEngine: encComplex128, decodeEngine: decComplex128, sizeEngine: sizeComplex128, Type: typeComplex128},
+		reflect.TypeOf((*[]byte)(nil)).Elem():         Scheme{encodeEngine: encBytes, decodeEngine: decBytes, sizeEngine: sizeBytes, Type: typeBytes},
+		reflect.TypeOf((*string)(nil)).Elem():         Scheme{encodeEngine: encString, decodeEngine: decString, sizeEngine: sizeString, Type: typeString},
+		reflect.TypeOf((*time.Time)(nil)).Elem():      Scheme{encodeEngine: encTime, decodeEngine: decTime, sizeEngine: sizeTime, Type: typeTime},
+		reflect.TypeOf((*struct{})(nil)).Elem():       Scheme{encodeEngine: encIgnore, decodeEngine: decIgnore, sizeEngine: sizeIgnore, Type: typeIgnore},
+		reflect.TypeOf(nil):                           Scheme{encodeEngine: encIgnore, decodeEngine: decIgnore, sizeEngine: sizeIgnore, Type: typeIgnore},
 	}
 	rtLock sync.RWMutex
+	// interfaceNodes caches nodes of dynamic types of interface values, reflect.Type to *Scheme,
+	// so engines of interfaces don't look them up in rt2Node under rtLock for every value
+	interfaceNodes sync.Map
+
+	kind2Type = [...]gotinyType{
+		reflect.Bool:          typeBool,
+		reflect.Int:           typeInt,
+		reflect.Int8:          typeInt8,
+		reflect.Int16:         typeInt16,
+		reflect.Int32:         typeInt32,
+		reflect.Int64:         typeInt64,
+		reflect.Uint:          typeUint,
+		reflect.Uint8:         typeUint8,
+		reflect.Uint16:        typeUint16,
+		reflect.Uint32:        typeUint32,
+		reflect.Uint64:        typeUint64,
+		reflect.Uintptr:       typeUint64,
+		reflect.UnsafePointer: typeUint64,
+		reflect.Float32:       typeFloat32,
+		reflect.Float64:       typeFloat64,
+		reflect.Complex64:     typeComplex64,
+		reflect.Complex128:    typeComplex128,
+		reflect.String:        typeString,
+	}
 
 	type2Empty = map[gotinyType]decEng{
 		typeIgnore:     func(d *Decoder, p unsafe.Pointer) {},
@@ -80,6 +142,7 @@ var (
 		typeFloat32:    skipUint32,
 		typeFloat64:    skipUint64,
 		typeBytes:      skipBytes,
+		typeString:     skipString,
 		typeTime:       skipUint64,
 		typeInterface:  skipPanic,
 		typePointer:    skipUint64,
@@ -88,6 +151,38 @@ var (
 	}
 )
 
+func init() {
+	for rt, node := range rt2Node {
+		if rt != nil {
+			node.GoType = GetNameByType(rt)
+			node.rt = rt
+			rt2Node[rt] = node
+		}
+	}
+}
+
+// schemeOfType returns scheme node with engines of rt
+func schemeOfType(rt reflect.Type) *Scheme {
+	rtLock.RLock()
+	node, ok := rt2Node[rt]
+	rtLock.RUnlock()
+	if !ok {
+		rtLock.Lock()
+		defer rtLock.Unlock() // building panics on types which can't be encoded
+		buildSchemeEngine("", rt, &node)
+	}
+	return &node
+}
+
+// interfaceNode returns scheme node with engines of rt, type of value of interface
+func interfaceNode(rt reflect.Type) *Scheme {
+	if node, ok := interfaceNodes.Load(rt); ok {
+		return node.(*Scheme)
+	}
+	node, _ := interfaceNodes.LoadOrStore(rt, schemeOfType(rt))
+	return node.(*Scheme)
+}
+
 // Coder provides single thread safe interface to encode and decode objects
 // for performance reasons encoders and decoders reusing using channel pool
 type Coder struct {
@@ -95,9 +190,13 @@ type Coder struct {
 	originalScheme Scheme
 	encodeEngines  []encEng // optimisation param for faster creation of encoder and decoder
 	decodeEngines  []decEng
+	sizeEngines    []sizeEng
 	encoder        chan *Encoder // to reuse existing encoders
 	decoder        chan *Decoder // to reuse existing decoders
 	length         int
+
+	zeroCopyStrings bool           // decoders are set to alias strings
+	interner        StringInterner // interner of decoders
 }
 
 // CoderNew creates new scheme oblect
@@ -109,6 +208,7 @@ func CoderNew(l int) *Coder {
 		},
 		encodeEngines: make([]encEng, l),
 		decodeEngines: make([]decEng, l),
+		sizeEngines:   make([]sizeEng, l),
 		encoder:       make(chan *Encoder, 10),
 		decoder:       make(chan *Decoder, 10),
 	}
@@ -158,15 +258,18 @@ func (c *Coder) GetScheme() *Scheme {
 func (c *Coder) SetScheme(scheme *Scheme) {
 	c.encodeEngines = []encEng{}
 	c.decodeEngines = []decEng{}
+	c.sizeEngines = []sizeEng{}
 
 	if len(c.originalScheme.Childs) != len(scheme.Childs) {
 		panic("setting scheme with different number of elements")
 	}
 
+	visited := map[*Scheme]bool{}
 	for i, child := range scheme.Childs {
-		child.fillEngines(c, c.originalScheme.Childs[i])
+		child.fillEngines(c, c.originalScheme.Childs[i], visited)
 		c.encodeEngines = append(c.encodeEngines, child.encodeEngine)
 		c.decodeEngines = append(c.decodeEngines, child.decodeEngine)
+		c.sizeEngines = append(c.sizeEngines, child.sizeEngine)
 	}
 	c.scheme = *scheme
 }
@@ -177,8 +280,9 @@ func (c *Coder) GetEncoder() (enc *Encoder) {
 	case enc = <-c.encoder:
 	default:
 		enc = &Encoder{
-			length:  c.length,
-			engines: c.encodeEngines,
+			length:      c.length,
+			engines:     c.encodeEngines,
+			sizeEngines: c.sizeEngines,
 		}
 	}
 	return
@@ -202,9 +306,24 @@ func (c *Coder) GetDecoder() (dec *Decoder) {
 			engines: c.decodeEngines,
 		}
 	}
+	dec.zeroCopyStrings = c.zeroCopyStrings
+	dec.interner = c.interner
+	dec.allocator = nil
 	return
 }
 
+// SetInterner makes decoders of coder take decoded strings from interner, see Decoder.SetInterner.
+// Interner may be shared by coders, it should be set before coder is used by different goroutines.
+func (c *Coder) SetInterner(interner StringInterner) {
+	c.interner = interner
+}
+
+// SetZeroCopyStrings makes decoders of coder decode strings aliasing decoded data, see Decoder.SetZeroCopyStrings.
+// It should be set before coder is used by different goroutines.
+func (c *Coder) SetZeroCopyStrings(zeroCopy bool) {
+	c.zeroCopyStrings = zeroCopy
+}
+
 // PutDecoder put decoder back for reuse
 func (c *Coder) PutDecoder(dec *Decoder) {
 	select {
@@ -213,30 +332,61 @@ func (c *Coder) PutDecoder(dec *Decoder) {
 	}
 }
 
-// Encode object using entry parameter as a pointer to the value to be encoded
+// Encode object using entry parameter as a pointer to the value to be encoded.
+// Returned data is owned by caller, it's copied from buffer of pooled encoder.
 func (c *Coder) Encode(is ...interface{}) []byte {
 	enc := c.GetEncoder()
-	data := enc.Encode(is...)
+	data := owned(enc.Encode(is...))
 	c.PutEncoder(enc)
 	return data
 }
 
-// EncodePtr the input parameter is the unsafe.Pointer pointer
+// EncodePtr the input parameter is the unsafe.Pointer pointer, returned data is owned by caller
 func (c *Coder) EncodePtr(ps ...unsafe.Pointer) []byte {
 	enc := c.GetEncoder()
-	data := enc.EncodePtr(ps...)
+	data := owned(enc.EncodePtr(ps...))
 	c.PutEncoder(enc)
 	return data
 }
 
-// EncodeValue the input parameter is the reflect.Value
+// EncodeValue the input parameter is the reflect.Value, returned data is owned by caller
 func (c *Coder) EncodeValue(vs ...reflect.Value) []byte {
 	enc := c.GetEncoder()
-	data := enc.EncodeValue(vs...)
+	data := owned(enc.EncodeValue(vs...))
 	c.PutEncoder(enc)
 	return data
 }
 
+// AppendEncode appends data of values to dst and returns extended slice, it doesn't allocate when dst has enough capacity.
+// Buffer of pooled encoder isn't used, so data belongs to caller as dst does.
+func (c *Coder) AppendEncode(dst []byte, is ...interface{}) []byte {
+	enc := c.GetEncoder()
+	dst = enc.AppendEncode(dst, is...)
+	c.PutEncoder(enc)
+	return dst
+}
+
+// EncodeInto writes data of values to beginning of buf without growing it, see Encoder.EncodeInto
+func (c *Coder) EncodeInto(buf []byte, is ...interface{}) (n int, err error) {
+	enc := c.GetEncoder()
+	n, err = enc.EncodeInto(buf, is...)
+	c.PutEncoder(enc)
+	return
+}
+
+// owned copies data from buffer of encoder, which is overwritten when encoder is reused
+func owned(data []byte) []byte {
+	return append(make([]byte, 0, len(data)), data...)
+}
+
+// Size returns length of data Encode would return for the same values without encoding them
+func (c *Coder) Size(is ...interface{}) int {
+	enc := c.GetEncoder()
+	n := enc.Size(is...)
+	c.PutEncoder(enc)
+	return n
+}
+
 // Decode decodes data with new decoder
 func (c *Coder) Decode(buf []byte, is ...interface{}) int {
 	dec := c.GetDecoder()
@@ -262,19 +412,11 @@ func (c *Coder) DecodeValue(buf []byte, vs ...reflect.Value) int {
 }
 
 func (c *Coder) getEngine(index int, rt reflect.Type) {
-	rtLock.RLock()
-	node, ok := rt2Node[rt]
-	rtLock.RUnlock()
-	if !ok {
-		rtLock.Lock()
-		buildSchemeEngine("", rt, &node)
-		//var scheme Scheme
-		//buildScheme("", rt, &scheme)
-		rtLock.Unlock()
-	}
-	c.scheme.Childs[index] = &node
+	node := schemeOfType(rt)
+	c.scheme.Childs[index] = node
 	c.encodeEngines[index] = node.encodeEngine
 	c.decodeEngines[index] = node.decodeEngine
+	c.sizeEngines[index] = node.sizeEngine
 	// keep original scheme to be able to apply multiple schemes on top
 	c.originalScheme = c.scheme
 }
@@ -287,10 +429,11 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 		return
 	}
 
-	node = Scheme{Name: name}
-	if encodeEngine, decodeEngine := implementOtherSerializer(rt); encodeEngine != nil {
+	node = Scheme{Name: name, GoType: GetNameByType(rt), rt: rt}
+	if encodeEngine, decodeEngine, sizeEngine := implementOtherSerializer(rt); encodeEngine != nil {
 		node.encodeEngine = encodeEngine
 		node.decodeEngine = decodeEngine
+		node.sizeEngine = sizeEngine
 		node.Type = typeCustom
 		rt2Node[rt] = node // put node to cache
 		*nodePtr = node
@@ -300,129 +443,35 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 	kind := rt.Kind()
 	switch kind {
 	case reflect.Ptr:
-		et := rt.Elem()
 		var eNode Scheme
-		node.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
-			isNotNil := !isNil(p)
-			e.encIsNotNil(isNotNil)
-			if isNotNil {
-				eNode.encodeEngine(e, *(*unsafe.Pointer)(p))
-			}
-		}
-		node.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
-			if d.decIsNotNil() {
-				if isNil(p) {
-					*(*unsafe.Pointer)(p) = unsafe.Pointer(reflect.New(et).Elem().UnsafeAddr())
-				}
-				eNode.decodeEngine(d, *(*unsafe.Pointer)(p))
-			} else if !isNil(p) {
-				*(*unsafe.Pointer)(p) = nil
-			}
-		}
-
 		node.Type = typePointer
 		node.Childs = []*Scheme{&eNode}
+		node.setPointerEngines(rt)
 		rt2Node[rt] = node
-		buildSchemeEngine("", et, &eNode)
+		buildSchemeEngine("", rt.Elem(), &eNode)
 	case reflect.Array:
-		et, l := rt.Elem(), rt.Len()
 		var eNode Scheme
-		size := et.Size()
-		node.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
-			for i := 0; i < l; i++ {
-				eNode.encodeEngine(e, unsafe.Pointer(uintptr(p)+uintptr(i)*size))
-			}
-		}
-		node.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
-			for i := 0; i < l; i++ {
-				eNode.decodeEngine(d, unsafe.Pointer(uintptr(p)+uintptr(i)*size))
-			}
-		}
-
 		node.Type = typeArray
+		node.Len = rt.Len()
 		node.Childs = []*Scheme{&eNode}
+		node.setArrayEngines(rt)
 		rt2Node[rt] = node
-		buildSchemeEngine("", et, &eNode)
+		buildSchemeEngine("", rt.Elem(), &eNode)
 	case reflect.Slice:
-		et := rt.Elem()
-		size := et.Size()
 		var eNode Scheme
-		node.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
-			isNotNil := !isNil(p)
-			e.encIsNotNil(isNotNil)
-			if isNotNil {
-				header := (*reflect.SliceHeader)(p)
-				l := header.Len
-				e.encLength(l)
-				for i := 0; i < l; i++ {
-					eNode.encodeEngine(e, unsafe.Pointer(header.Data+uintptr(i)*size))
-				}
-			}
-		}
-		node.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
-			header := (*reflect.SliceHeader)(p)
-			if d.decIsNotNil() {
-				l := d.decLength()
-				if isNil(p) || header.Cap < l {
-					*header = reflect.SliceHeader{Data: reflect.MakeSlice(rt, l, l).Pointer(), Len: l, Cap: l}
-				} else {
-					header.Len = l
-				}
-				for i := 0; i < l; i++ {
-					eNode.decodeEngine(d, unsafe.Pointer(header.Data+uintptr(i)*size))
-				}
-			} else if !isNil(p) {
-				*header = reflect.SliceHeader{}
-			}
-		}
 		node.Type = typeSlice
 		node.Childs = []*Scheme{&eNode}
+		node.setSliceEngines(rt)
 		rt2Node[rt] = node
-		buildSchemeEngine("", et, &eNode)
+		buildSchemeEngine("", rt.Elem(), &eNode)
 	case reflect.Map:
 		var kNode, eNode Scheme
-		kt, vt := rt.Key(), rt.Elem()
-		skt, svt := reflect.SliceOf(kt), reflect.SliceOf(vt)
-		node.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
-			isNotNil := !isNil(p)
-			e.encIsNotNil(isNotNil)
-			if isNotNil {
-				v := reflect.NewAt(rt, p).Elem()
-				e.encLength(v.Len())
-				keys := v.MapKeys()
-				for i := 0; i < len(keys); i++ {
-					val := v.MapIndex(keys[i])
-					kNode.encodeEngine(e, getUnsafePointer(&keys[i]))
-					eNode.encodeEngine(e, getUnsafePointer(&val))
-				}
-			}
-		}
-		node.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
-			if d.decIsNotNil() {
-				l := d.decLength()
-				var v reflect.Value
-				if isNil(p) {
-					v = reflect.MakeMapWithSize(rt, l)
-					*(*unsafe.Pointer)(p) = unsafe.Pointer(v.Pointer())
-				} else {
-					v = reflect.NewAt(rt, p).Elem()
-				}
-				keys, vals := reflect.MakeSlice(skt, l, l), reflect.MakeSlice(svt, l, l)
-				for i := 0; i < l; i++ {
-					key, val := keys.Index(i), vals.Index(i)
-					kNode.decodeEngine(d, unsafe.Pointer(key.UnsafeAddr()))
-					eNode.decodeEngine(d, unsafe.Pointer(val.UnsafeAddr()))
-					v.SetMapIndex(key, val)
-				}
-			} else if !isNil(p) {
-				*(*unsafe.Pointer)(p) = nil
-			}
-		}
 		node.Type = typeMap
 		node.Childs = []*Scheme{&kNode, &eNode}
+		node.setMapEngines(rt)
 		rt2Node[rt] = node
-		buildSchemeEngine("", kt, &kNode)
-		buildSchemeEngine("", vt, &eNode)
+		buildSchemeEngine("key", rt.Key(), &kNode)
+		buildSchemeEngine("value", rt.Elem(), &eNode)
 	case reflect.Struct:
 		/*names, fields, offs := getFieldType(rt, 0)
 		nf := len(fields)
@@ -447,9 +496,14 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 			buildSchemeEngine(names[i], fields[i], fNodes[i])
 		}*/
 
-		names, fields, offs := getFieldType(rt, 0)
+		names, fields, offs, packed := getFieldType(rt, 0)
 		nf := len(fields)
 		fNodes := make([]*Scheme, nf)
+		for i := 0; i < nf; i++ {
+			if packed[i] {
+				checkPacked(fields[i], rt.String()+"."+names[i])
+			}
+		}
 
 		node.Childs = fNodes
 		node.Type = typeStruct
@@ -459,6 +513,9 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 			fNodes[i] = &Scheme{}
 			buildSchemeEngine(names[i], fields[i], fNodes[i])
 			fNodes[i].offset = offs[i]
+			if packed[i] {
+				fNodes[i].setPacked(fields[i], rt.String()+"."+names[i])
+			}
 		}
 
 	case reflect.Interface:
@@ -473,9 +530,7 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 					et := v.Type()
 					e.encString(getNameOfType(et))
 
-					var interfaceNode Scheme
-					buildSchemeEngine("", et, &interfaceNode)
-					interfaceNode.encodeEngine(e, getUnsafePointer(&v))
+					interfaceNode(et).encodeEngine(e, getUnsafePointer(&v))
 				}
 			}
 		} else {
@@ -487,17 +542,25 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 					et := v.Type()
 					e.encString(getNameOfType(et))
 
-					var interfaceNode Scheme
-					buildSchemeEngine("", et, &interfaceNode)
-					interfaceNode.encodeEngine(e, getUnsafePointer(&v))
+					interfaceNode(et).encodeEngine(e, getUnsafePointer(&v))
 				}
 			}
 		}
+		node.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+			e.sizeBool()
+			if !isNil(p) {
+				v := reflect.NewAt(rt, p).Elem().Elem()
+				et := v.Type()
+				e.sizeBlock(len(getNameOfType(et)))
+
+				interfaceNode(et).sizeEngine(e, getUnsafePointer(&v))
+			}
+		}
 		node.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
 			if d.decIsNotNil() {
 				name := ""
 				decString(d, unsafe.Pointer(&name))
-				et, has := name2type[name]
+				et, has := typeByName(name)
 				if !has {
 					panic("unknown typ:" + name)
 				}
@@ -508,9 +571,7 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 				} else {
 					ev = v.Elem()
 				}
-				var interfaceNode Scheme
-				buildSchemeEngine("", et, &interfaceNode)
-				interfaceNode.decodeEngine(d, getUnsafePointer(&ev))
+				interfaceNode(et).decodeEngine(d, getUnsafePointer(&ev))
 				v.Set(ev)
 			} else if !isNil(p) {
 				*(*unsafe.Pointer)(p) = nil
@@ -523,6 +584,8 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 	default:
 		node.encodeEngine = encEngines[kind]
 		node.decodeEngine = decEngines[kind]
+		node.sizeEngine = sizeEngines[kind]
+		node.Type = kind2Type[kind]
 		rt2Node[rt] = node
 	}
 	*nodePtr = node
@@ -530,6 +593,8 @@ func buildSchemeEngine(name string, rt reflect.Type, nodePtr *Scheme) {
 
 // UnusedUnixNanoEncodeTimeType removes unused time
 func UnusedUnixNanoEncodeTimeType() {
+	rtLock.Lock()
 	delete(rt2Node, reflect.TypeOf((*time.Time)(nil)).Elem())
-	delete(rt2Node, reflect.TypeOf((*time.Time)(nil)).Elem())
+	rtLock.Unlock()
+	interfaceNodes.Delete(reflect.TypeOf((*time.Time)(nil)).Elem())
 }
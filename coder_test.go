@@ -0,0 +1,231 @@
+package gotiny_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+// TestCoderEncodeOwned checks data returned by Encode isn't overwritten when pooled encoder is reused
+func TestCoderEncodeOwned(t *testing.T) {
+	coder := gotiny.New("", 0)
+	s, i := "first", 1
+	first := coder.Encode(&s, &i)
+	exp := append([]byte{}, first...)
+	s, i = "second value", 2
+	coder.Encode(&s, &i)
+	if !bytes.Equal(first, exp) {
+		t.Errorf("expected %v, got %v", exp, first)
+	}
+}
+
+func TestCoderConcurrent(t *testing.T) {
+	coder := gotiny.New("", []int{})
+	const n = 32
+	results := make([][]byte, n)
+	var wg sync.WaitGroup
+	for g := 0; g < n; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			s, l := strconv.Itoa(g), make([]int, g)
+			for k := 0; k < 100; k++ {
+				if k%2 == 0 {
+					results[g] = coder.Encode(&s, &l)
+				} else {
+					results[g] = coder.AppendEncode(results[g][:0], &s, &l)
+				}
+				var rs string
+				var rl []int
+				coder.Decode(results[g], &rs, &rl)
+				if rs != s || len(rl) != g {
+					t.Errorf("%d: decoded %q %v", g, rs, rl)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	for g, data := range results {
+		var s string
+		var l []int
+		coder.Decode(data, &s, &l)
+		if s != strconv.Itoa(g) || len(l) != g {
+			t.Errorf("%d: data was changed, decoded %q %v", g, s, l)
+		}
+	}
+}
+
+type concurrentA struct{ S string }
+
+// TestCoderInterfaceConcurrent checks engines of interfaces under go test -race.
+// Every round encodes values of new types, so their engines are built and registered concurrently,
+// goroutines use own encoders and decoders, pools of coder would order their accesses.
+func TestCoderInterfaceConcurrent(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	const goroutines = 8
+	encoders, decoders := make([]*gotiny.Encoder, goroutines), make([]*gotiny.Decoder, goroutines)
+	for g := range encoders {
+		encoders[g] = gotiny.NewEncoderWithPtr(&[]interface{}{})
+		decoders[g] = gotiny.NewDecoderWithPtr(&[]interface{}{})
+	}
+	for round := 1; round <= 20; round++ {
+		rt := reflect.ArrayOf(round, reflect.TypeOf(concurrentA{}))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(e *gotiny.Encoder, d *gotiny.Decoder) {
+				defer wg.Done()
+				v := reflect.New(rt).Elem()
+				v.Index(0).Field(0).SetString(rt.String())
+				src := []interface{}{v.Interface(), nil}
+				<-start
+				data := e.Encode(&src)
+				if size := e.Size(&src); size != len(data) {
+					t.Errorf("%s: size %d, encoded %d bytes", rt, size, len(data))
+				}
+				var got []interface{}
+				d.Decode(data, &got)
+				if !reflect.DeepEqual(got, src) {
+					t.Errorf("%s: expected %v, got %v", rt, src, got)
+				}
+			}(encoders[g], decoders[g])
+		}
+		close(start)
+		wg.Wait()
+	}
+}
+
+func TestAppendEncode(t *testing.T) {
+	coder := gotiny.New("")
+	s := "hello"
+	dst := coder.AppendEncode([]byte("prefix"), &s)
+	if exp := append([]byte("prefix"), coder.Encode(&s)...); !bytes.Equal(dst, exp) {
+		t.Errorf("expected %v, got %v", exp, dst)
+	}
+
+	buf := make([]byte, 0, 64)
+	if n := testing.AllocsPerRun(100, func() { buf = coder.AppendEncode(buf[:0], &s) }); n != 0 {
+		t.Errorf("expected no allocations, got %v", n)
+	}
+	// encoder keeps appending to its own buffer after appending to dst
+	enc := gotiny.NewEncoder("")
+	enc.AppendTo([]byte("own"))
+	enc.AppendEncode(buf[:0], &s)
+	if data := enc.Encode(&s); !bytes.HasPrefix(data, []byte("own")) || !bytes.Equal(data[3:], buf) {
+		t.Errorf("unexpected data %v", data)
+	}
+}
+
+func TestEncodeInto(t *testing.T) {
+	coder := gotiny.New("", map[int]string{})
+	s, m := "hello", map[int]string{1: "a"} // one entry, order of map entries changes
+	exp := coder.Encode(&s, &m)
+
+	buf := make([]byte, len(exp))
+	n, err := coder.EncodeInto(buf, &s, &m)
+	if err != nil || n != len(exp) || !bytes.Equal(buf, exp) {
+		t.Errorf("expected %v, got %v %d %v", exp, buf, n, err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { coder.EncodeInto(buf, &s, &m) }); allocs != 0 && !raceEnabled {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+
+	short := make([]byte, len(exp)-1, len(exp)*2)
+	n, err = coder.EncodeInto(short, &s, &m)
+	if err != io.ErrShortBuffer || n != len(exp) {
+		t.Errorf("expected %d and %v, got %d %v", len(exp), io.ErrShortBuffer, n, err)
+	}
+	if !bytes.Equal(short, make([]byte, len(short))) {
+		t.Errorf("short buffer was written: %v", short)
+	}
+}
+
+type zeroCopyT struct {
+	Name  string
+	Tags  []string
+	Attrs map[string]string
+	Empty string
+}
+
+func TestZeroCopyStrings(t *testing.T) {
+	src := zeroCopyT{Name: "name", Tags: []string{"a", "bc"}, Attrs: map[string]string{"k": "v"}}
+	coder := gotiny.New(zeroCopyT{})
+	data := coder.Encode(&src)
+
+	var copied zeroCopyT
+	coder.Decode(data, &copied)
+	coder.SetZeroCopyStrings(true)
+	var aliased zeroCopyT
+	coder.Decode(data, &aliased)
+	Assert(t, data, src, aliased)
+	if allocs := testing.AllocsPerRun(100, func() { coder.Decode(data, &aliased) }); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+
+	// aliased strings see changes of data, copied don't
+	for i := range data {
+		if data[i] == 'n' {
+			data[i] = 'N'
+		}
+	}
+	if aliased.Name != "Name" {
+		t.Errorf("expected aliased string Name, got %s", aliased.Name)
+	}
+	if copied.Name != "name" {
+		t.Errorf("expected copied string name, got %s", copied.Name)
+	}
+
+	coder.SetZeroCopyStrings(false)
+	var again zeroCopyT
+	coder.Decode(data, &again)
+	data[0] = 0
+	if again.Name != "Name" {
+		t.Errorf("expected copied string after option is off, got %s", again.Name)
+	}
+}
+
+func TestZeroCopyStringsTruncated(t *testing.T) {
+	s := "hello"
+	data := gotiny.Marshal(&s)
+	d := gotiny.NewDecoderWithPtr(&s)
+	d.SetZeroCopyStrings(true)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on truncated data")
+		}
+	}()
+	// capacity of data is larger than length, string must not alias bytes beyond length
+	d.Decode(append(make([]byte, 0, 64), data[:len(data)-1]...), &s)
+}
+
+// TestZeroCopyStringsConcurrent decodes the same immutable data from many goroutines
+func TestZeroCopyStringsConcurrent(t *testing.T) {
+	coder := gotiny.New(zeroCopyT{})
+	coder.SetZeroCopyStrings(true)
+	src := zeroCopyT{Name: "shared", Tags: []string{"x", "y", "z"}}
+	data := coder.Encode(&src)
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				var got zeroCopyT
+				coder.Decode(data, &got)
+				if got.Name != src.Name || len(got.Tags) != 3 || got.Tags[2] != "z" {
+					t.Errorf("unexpected value %+v", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,303 @@
+package gotiny
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compatibility describes whether data written with one scheme can be read with another one
+type Compatibility uint8
+
+const (
+	// Compatible change doesn't affect decoding
+	Compatible Compatibility = iota
+	// BackwardCompatible reader expects data which writer doesn't produce, it keeps zero value
+	BackwardCompatible
+	// ForwardCompatible writer produces data which reader doesn't know about, it is skipped on decode
+	ForwardCompatible
+	// Breaking reader can't decode data of writer or values are silently lost
+	Breaking
+)
+
+var compatibilityNames = [...]string{
+	Compatible:         "compatible",
+	BackwardCompatible: "backward-compatible",
+	ForwardCompatible:  "forward-compatible",
+	Breaking:           "breaking",
+}
+
+func (c Compatibility) String() string {
+	if int(c) < len(compatibilityNames) {
+		return compatibilityNames[c]
+	}
+	return "compatibility(" + strconv.Itoa(int(c)) + ")"
+}
+
+// ChangeKind is a kind of difference between writer and reader schemes
+type ChangeKind uint8
+
+const (
+	// FieldAdded field exists only in reader scheme
+	FieldAdded ChangeKind = iota + 1
+	// FieldRemoved field exists only in writer scheme
+	FieldRemoved
+	// FieldRenamed removed field is encoded in the same way as the added one at the same position
+	FieldRenamed
+	// TypeChanged field exists in both schemes but has different type
+	TypeChanged
+	// FieldsReordered struct fields exist in both schemes but in different order
+	FieldsReordered
+	// ElementChanged slice, array, map or pointer element scheme differs.
+	//
+	// Deprecated: changes of elements are reported at their paths with other kinds, ElementChanged isn't reported.
+	ElementChanged
+	// ValuesChanged schemes describe different number of encoded values
+	ValuesChanged
+)
+
+var changeKindNames = [...]string{
+	FieldAdded:      "added",
+	FieldRemoved:    "removed",
+	FieldRenamed:    "renamed",
+	TypeChanged:     "type changed",
+	FieldsReordered: "reordered",
+	ElementChanged:  "element changed",
+	ValuesChanged:   "values changed",
+}
+
+func (k ChangeKind) String() string {
+	if int(k) < len(changeKindNames) && changeKindNames[k] != "" {
+		return changeKindNames[k]
+	}
+	return "change(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Change is a single difference found by CheckCompatibility
+type Change struct {
+	Path          string        // path of the node, e.g. [0].Items[].Price
+	Kind          ChangeKind    // what has changed
+	Compatibility Compatibility // how the change affects decoding of writer data by reader
+	Writer        *Scheme       // node of writer scheme, nil for added fields
+	Reader        *Scheme       // node of reader scheme, nil for removed fields
+	Detail        string        // human readable explanation
+}
+
+func (c Change) String() string {
+	s := c.Path + ": " + c.Kind.String() + " (" + c.Compatibility.String() + ")"
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// Report is a result of scheme compatibility check
+type Report struct {
+	Changes []Change
+}
+
+// Breaking returns true if at least one change doesn't allow reader to decode writer data
+func (r Report) Breaking() bool {
+	for _, change := range r.Changes {
+		if change.Compatibility == Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Report) String() string {
+	lines := make([]string, len(r.Changes))
+	for i, change := range r.Changes {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckCompatibility walks scheme used to encode data (writer) and scheme of the objects
+// data will be decoded to (reader) and classifies every difference in the way
+// Coder.SetScheme is able to handle it.
+// Both schemes may be either full coder schemes as returned by Coder.GetScheme
+// or single nodes of scheme.
+func CheckCompatibility(writer, reader *Scheme) Report {
+	c := compatChecker{visited: map[[2]*Scheme]bool{}}
+	if writer.isRoot() || reader.isRoot() {
+		c.root(writer, reader)
+	} else {
+		c.node("", writer, reader)
+	}
+	return Report{Changes: c.changes}
+}
+
+type compatChecker struct {
+	changes []Change
+	visited map[[2]*Scheme]bool // protects from recursive types
+}
+
+func (c *compatChecker) add(path string, kind ChangeKind, compat Compatibility, w, r *Scheme, detail string) {
+	c.changes = append(c.changes, Change{Path: path, Kind: kind, Compatibility: compat, Writer: w, Reader: r, Detail: detail})
+}
+
+// root compares top level values, they are matched by position as Coder.SetScheme does
+func (c *compatChecker) root(w, r *Scheme) {
+	if len(w.Childs) != len(r.Childs) {
+		c.add("", ValuesChanged, Breaking, w, r,
+			"writer encodes "+strconv.Itoa(len(w.Childs))+" values, reader decodes "+strconv.Itoa(len(r.Childs)))
+		return
+	}
+	for i := range w.Childs {
+		c.node("["+strconv.Itoa(i)+"]", w.Childs[i], r.Childs[i])
+	}
+}
+
+func (c *compatChecker) node(path string, w, r *Scheme) {
+	pair := [2]*Scheme{w, r}
+	if c.visited[pair] {
+		return
+	}
+	c.visited[pair] = true
+
+	if !w.sameType(r) {
+		c.add(path, TypeChanged, Breaking, w, r, typeLabel(w)+" -> "+typeLabel(r)+", value is dropped")
+		return
+	}
+	switch w.Type {
+	case typeStruct:
+		c.structFields(path, w, r)
+	case typeSlice, typeArray, typeMap, typePointer:
+		// elements of containers are migrated by position
+		for i := 0; i < len(w.Childs) && i < len(r.Childs); i++ {
+			c.node(path+elemPath(w.Type, i), w.Childs[i], r.Childs[i])
+		}
+	}
+}
+
+func typeLabel(s *Scheme) string {
+	if s.Packed {
+		return "packed " + typeLabel(&Scheme{Type: s.Type, Len: s.Len})
+	}
+	if s.Type == typeArray && s.Len != 0 {
+		return "[" + strconv.Itoa(s.Len) + "]" + s.Type.String()
+	}
+	return s.Type.String()
+}
+
+func (c *compatChecker) structFields(path string, w, r *Scheme) {
+	matched := make(map[*Scheme]bool, len(r.Childs))
+	var removed []int
+	var common []string
+	for i, wChild := range w.Childs {
+		rChild := r.find(wChild)
+		if rChild == nil {
+			removed = append(removed, i)
+			continue
+		}
+		matched[rChild] = true
+		common = append(common, wChild.Name)
+		c.node(path+"."+wChild.Name, wChild, rChild)
+	}
+
+	// k-th removed field is considered renamed to k-th added one if they are encoded in the same way
+	var added []*Scheme
+	for _, rChild := range r.Childs {
+		if !matched[rChild] {
+			added = append(added, rChild)
+		}
+	}
+	renamed := map[*Scheme]bool{}
+	for k, i := range removed {
+		wChild := w.Childs[i]
+		if k < len(added) && sameScheme(wChild, added[k], map[[2]*Scheme]bool{}) {
+			rChild := added[k]
+			renamed[rChild] = true
+			c.add(path+"."+wChild.Name, FieldRenamed, Breaking, wChild, rChild,
+				"renamed to "+rChild.Name+", fields are matched by name so value is dropped")
+			continue
+		}
+		if skippable(wChild, map[*Scheme]bool{}) {
+			c.add(path+"."+wChild.Name, FieldRemoved, ForwardCompatible, wChild, nil, "value is skipped")
+		} else {
+			c.add(path+"."+wChild.Name, FieldRemoved, Breaking, wChild, nil, typeLabel(wChild)+" value can't be skipped")
+		}
+	}
+
+	var readerOrder []string
+	for _, rChild := range r.Childs {
+		if matched[rChild] {
+			readerOrder = append(readerOrder, rChild.Name)
+		} else if !renamed[rChild] {
+			c.add(path+"."+rChild.Name, FieldAdded, BackwardCompatible, nil, rChild, "keeps zero value")
+		}
+	}
+
+	for i := range common {
+		if common[i] != readerOrder[i] {
+			c.add(path, FieldsReordered, Compatible, w, r, "fields are decoded in writer order")
+			break
+		}
+	}
+}
+
+func elemPath(t gotinyType, i int) string {
+	switch t {
+	case typePointer:
+		return "*"
+	case typeMap:
+		if i == 0 {
+			return "{key}"
+		}
+		return "{value}"
+	}
+	return "[]"
+}
+
+// isRoot reports whether scheme is a list of encoded values as returned by Coder.GetScheme
+func (s *Scheme) isRoot() bool {
+	return s.Type == typeIgnore && s.Name == "" && len(s.Childs) > 0
+}
+
+// sameScheme reports whether nodes are encoded in the same way,
+// names are only significant for struct fields
+func sameScheme(a, b *Scheme, visited map[[2]*Scheme]bool) bool {
+	pair := [2]*Scheme{a, b}
+	if visited[pair] {
+		return true
+	}
+	visited[pair] = true
+	if !a.sameType(b) || len(a.Childs) != len(b.Childs) {
+		return false
+	}
+	for i := range a.Childs {
+		if a.Type == typeStruct && a.Childs[i].Name != b.Childs[i].Name {
+			return false
+		}
+		if !sameScheme(a.Childs[i], b.Childs[i], visited) {
+			return false
+		}
+	}
+	return true
+}
+
+// skippable mirrors setEmptyEngines: reports whether decoder is able to skip value of scheme
+func skippable(s *Scheme, visited map[*Scheme]bool) bool {
+	if s.unknownKind() {
+		return false
+	}
+	switch s.Type {
+	case typeStruct:
+		if visited[s] {
+			return true
+		}
+		visited[s] = true
+		for _, child := range s.Childs {
+			if !skippable(child, visited) {
+				return false
+			}
+		}
+		return true
+	case typeInterface, typePointer:
+		// interface skipper panics, pointer is skipped as varint which doesn't match its encoding
+		return false
+	}
+	_, ok := type2Empty[s.Type]
+	return ok
+}
@@ -0,0 +1,119 @@
+package gotiny_test
+
+import (
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type (
+	compatItem struct {
+		Price int
+	}
+	compatItem2 struct {
+		Price int
+		Count int
+	}
+
+	compatV1 struct {
+		ID    uint32
+		Name  string
+		Old   int64
+		Tags  []string
+		Items []compatItem
+		Score int32
+	}
+	compatV2 struct {
+		Name  string
+		ID    uint32
+		New   bool
+		Items []compatItem2
+		Label []string
+		Score int64
+	}
+
+	compatTree struct {
+		Value    int
+		Children []*compatTree
+	}
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	writer := gotiny.New(compatV1{}).GetScheme()
+	reader := gotiny.New(compatV2{}).GetScheme()
+	report := gotiny.CheckCompatibility(writer, reader)
+
+	exp := map[string]struct {
+		kind   gotiny.ChangeKind
+		compat gotiny.Compatibility
+	}{
+		"[0]":               {gotiny.FieldsReordered, gotiny.Compatible},
+		"[0].Old":           {gotiny.FieldRemoved, gotiny.ForwardCompatible},
+		"[0].Tags":          {gotiny.FieldRenamed, gotiny.Breaking},
+		"[0].New":           {gotiny.FieldAdded, gotiny.BackwardCompatible},
+		"[0].Items[].Count": {gotiny.FieldAdded, gotiny.BackwardCompatible},
+		"[0].Score":         {gotiny.TypeChanged, gotiny.Breaking},
+	}
+	if len(report.Changes) != len(exp) {
+		t.Fatalf("expected %d changes, got:\n%s", len(exp), report)
+	}
+	for _, change := range report.Changes {
+		e, ok := exp[change.Path]
+		if !ok || e.kind != change.Kind || e.compat != change.Compatibility {
+			t.Errorf("unexpected change %s", change)
+		}
+	}
+	if !report.Breaking() {
+		t.Error("report should be breaking")
+	}
+}
+
+func TestCheckCompatibilityDecodable(t *testing.T) {
+	type v1 struct {
+		A   int
+		B   string
+		Old float64
+	}
+	type v2 struct {
+		B   string
+		New []int
+		A   int
+	}
+	writer, reader := gotiny.New(v1{}), gotiny.New(v2{})
+	report := gotiny.CheckCompatibility(writer.GetScheme(), reader.GetScheme())
+	if report.Breaking() {
+		t.Fatalf("unexpected breaking changes:\n%s", report)
+	}
+
+	scheme, err := gotiny.SchemeFromJSON(writer.GetScheme().AsJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.SetScheme(scheme)
+	var got v2
+	reader.Decode(writer.Encode(&v1{A: 3, B: "b", Old: 1.5}), &got)
+	if got.A != 3 || got.B != "b" || got.New != nil {
+		t.Errorf("unexpected decoded value %+v", got)
+	}
+}
+
+func TestCheckCompatibilityRecursive(t *testing.T) {
+	scheme := gotiny.New(compatTree{}).GetScheme()
+	if report := gotiny.CheckCompatibility(scheme, scheme); len(report.Changes) != 0 {
+		t.Errorf("unexpected changes:\n%s", report)
+	}
+}
+
+func TestCheckCompatibilityValues(t *testing.T) {
+	report := gotiny.CheckCompatibility(gotiny.New(1, "").GetScheme(), gotiny.New(1).GetScheme())
+	if len(report.Changes) != 1 || report.Changes[0].Kind != gotiny.ValuesChanged || !report.Breaking() {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestCheckCompatibilityArrayLen(t *testing.T) {
+	report := gotiny.CheckCompatibility(gotiny.New([3]int{}).GetScheme(), gotiny.New([4]int{}).GetScheme())
+	if len(report.Changes) != 1 || report.Changes[0].Kind != gotiny.TypeChanged || !report.Breaking() {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
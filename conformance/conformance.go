@@ -0,0 +1,204 @@
+// Package conformance describes wire format of gotiny by test vectors, so readers and writers
+// in other languages can check themselves against go implementation.
+//
+// Vectors are generated by gen.go into testdata/v<Version>.json, which is checked in:
+//
+//	go generate github.com/niubaoshu/gotiny/conformance
+//
+// Every vector has scheme of coder of one value as written by Scheme.AsJSON, value in form
+// written by gotiny.ToJSON and bytes encoded by go implementation in hex. Schemes of types of
+// interface values are listed in Types of suite by names the values are encoded with.
+// Tests of this package fail when bytes change, such change is incompatible and needs new Version.
+package conformance
+
+//go:generate go run gen.go
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+// Version is version of wire format described by vectors
+const Version = 1
+
+// Suite is content of file with vectors
+type Suite struct {
+	Version int                        `json:"version"`
+	Types   map[string]json.RawMessage `json:"types"` // schemes of types of interface values by their names
+	Vectors []Vector                   `json:"vectors"`
+}
+
+// Vector is one value encoded by gotiny
+type Vector struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Scheme      json.RawMessage `json:"scheme"`
+	Value       json.RawMessage `json:"value"` // list of top level values as written by gotiny.ToJSON
+	Hex         string          `json:"hex"`
+}
+
+type (
+	// Shape is encoded with name of type of its value
+	Shape interface{ Area() float64 }
+
+	// Circle is Shape encoded with name "conformance.Circle"
+	Circle struct{ R float64 }
+
+	// Square is Shape encoded with name "conformance.Square"
+	Square struct{ Side uint16 }
+
+	// Nested has bools sharing byte with bools of enclosing struct
+	Nested struct {
+		X, Y bool
+		V    uint8
+		Z    bool
+	}
+
+	// Flags has bools and nil flags in nested fields
+	Flags struct {
+		A bool
+		N Nested
+		P *Nested
+		L []bool
+		B bool
+	}
+
+	// Nils has every kind of value which may be nil
+	Nils struct {
+		P *int32
+		S []string
+		M map[string]int8
+		B []byte
+		I Shape
+	}
+)
+
+// Area of circle
+func (c Circle) Area() float64 { return math.Pi * c.R * c.R }
+
+// Area of square
+func (s Square) Area() float64 { return float64(s.Side) * float64(s.Side) }
+
+// impls are types of interface values with names they are encoded with
+var impls = []struct {
+	name string
+	typ  reflect.Type
+}{
+	{"conformance.Circle", reflect.TypeOf(Circle{})},
+	{"conformance.Square", reflect.TypeOf(Square{})},
+}
+
+func init() {
+	for _, impl := range impls {
+		gotiny.RegisterName(impl.name, impl.typ)
+	}
+}
+
+type testCase struct {
+	name, description string
+	value             interface{}
+}
+
+// Generate encodes values of all vectors and returns content of file with them
+func Generate() ([]byte, error) {
+	suite := Suite{Version: Version, Types: map[string]json.RawMessage{}}
+	for _, impl := range impls {
+		suite.Types[impl.name] = json.RawMessage(gotiny.NewWithType(impl.typ).GetScheme().Childs[0].AsJSON())
+	}
+	for _, c := range cases() {
+		coder := gotiny.New(c.value)
+		ptr := reflect.New(reflect.TypeOf(c.value))
+		ptr.Elem().Set(reflect.ValueOf(c.value))
+		buf := coder.Encode(ptr.Interface())
+		value, err := gotiny.ToJSON(coder.GetScheme(), buf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.name, err)
+		}
+		suite.Vectors = append(suite.Vectors, Vector{
+			Name:        c.name,
+			Description: c.description,
+			Scheme:      json.RawMessage(coder.GetScheme().AsJSON()),
+			Value:       value,
+			Hex:         hex.EncodeToString(buf),
+		})
+	}
+	data, err := json.MarshalIndent(suite, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// cases lists values of vectors, names are unique
+func cases() []testCase {
+	var cs []testCase
+	add := func(name, description string, values ...interface{}) {
+		for i, v := range values {
+			cs = append(cs, testCase{fmt.Sprintf("%s/%d", name, i), description, v})
+		}
+	}
+	// varints are shorter by byte when value is less than 1<<7k-1, so 1<<7k-1 takes the longer form
+	var u64 []interface{}
+	for k := uint(7); k < 64; k += 7 {
+		u64 = append(u64, uint64(1)<<k-2, uint64(1)<<k-1, uint64(1)<<k)
+	}
+	add("uint64", "varint, 7 bits in byte from lowest, high bit is set in all bytes but last, 9th byte has 8 bits",
+		append([]interface{}{uint64(0), uint64(1), uint64(math.MaxUint64)}, u64...)...)
+	var u32 []interface{}
+	for k := uint(7); k < 32; k += 7 {
+		u32 = append(u32, uint32(1)<<k-2, uint32(1)<<k-1, uint32(1)<<k)
+	}
+	add("uint32", "varint of at most 5 bytes", append([]interface{}{uint32(0), uint32(math.MaxUint32)}, u32...)...)
+	add("uint16", "varint of at most 3 bytes", uint16(0), uint16(126), uint16(127), uint16(128),
+		uint16(1<<14-2), uint16(1<<14-1), uint16(1<<14), uint16(math.MaxUint16))
+	add("uint", "varint as uint64", uint(0), uint(300))
+	add("uint8", "single byte", uint8(0), uint8(math.MaxUint8))
+	add("int8", "single byte of two's complement", int8(0), int8(-1), int8(math.MinInt8), int8(math.MaxInt8))
+
+	// zigzag maps 0, -1, 1, -2... into 0, 1, 2, 3...
+	add("int16", "zigzag varint as uint16", int16(0), int16(-1), int16(1), int16(63), int16(-63), int16(-64), int16(64),
+		int16(math.MinInt16), int16(math.MaxInt16))
+	add("int32", "zigzag varint as uint32", int32(0), int32(-1), int32(1), int32(-64), int32(64),
+		int32(math.MinInt32), int32(math.MaxInt32))
+	add("int64", "zigzag varint as uint64", int64(0), int64(-1), int64(1), int64(-64), int64(64),
+		int64(math.MinInt64), int64(math.MaxInt64))
+	add("int", "zigzag varint as int64", int(-1), int(300))
+
+	// floats are varints of IEEE 754 bits with reversed bytes, so floats with few bits of mantissa are short
+	add("float32", "varint as uint32 of bits with reversed bytes", float32(0), float32(math.Copysign(0, -1)), float32(1), float32(-2.5),
+		float32(0.1), float32(math.MaxFloat32), float32(math.SmallestNonzeroFloat32), float32(math.Inf(1)), float32(math.NaN()))
+	add("float64", "varint as uint64 of bits with reversed bytes", float64(0), math.Copysign(0, -1), float64(1), float64(-2.5),
+		float64(0.1), math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(-1), math.NaN())
+	add("complex64", "real and imaginary parts as float32", complex64(complex(1, -2.5)))
+	add("complex128", "real and imaginary parts as float64", complex(0.1, 1))
+
+	add("bool", "bit 0 of byte", false, true)
+	add("bools", "bools are packed into bits of byte from lowest, 9th bool starts new byte",
+		[9]bool{true, false, true, false, false, false, false, true, true})
+	add("flags", "bools of nested structs and not nil flags of pointers and slices share byte written at first bool, "+
+		"bytes of other values follow it",
+		Flags{},
+		Flags{A: true, N: Nested{Y: true, V: 7, Z: true}, P: &Nested{X: true}, L: []bool{true, true}, B: true})
+
+	add("string", "length as uint32 varint followed by bytes", "", "a", "héllo", strings.Repeat("x", 126), strings.Repeat("y", 127))
+	add("bytes", "not nil bit, then length and bytes when not nil", []byte(nil), []byte{}, []byte{0, 0xff})
+	add("time", "UnixNano as varint of uint64, not zigzag", time.Unix(0, 0).UTC(), time.Unix(1500000000, 123456789).UTC())
+	add("array", "elements without length", [3]int16{1, -1, 300})
+	add("pointer", "not nil bit, then value when not nil", (*int8)(nil), func() *int8 { i := int8(-5); return &i }())
+
+	// maps are written in order of iteration, so vectors have at most one entry
+	add("nils", "nil pointers, slices, maps, []byte and interfaces are written as unset not nil bits",
+		Nils{},
+		Nils{P: new(int32), S: []string{}, M: map[string]int8{}, B: []byte{}, I: Square{}},
+		Nils{P: func() *int32 { i := int32(-300); return &i }(), S: []string{"a", ""}, M: map[string]int8{"k": -1}, B: []byte{1}, I: Circle{R: 1.5}})
+	add("interface", "not nil bit, then name of type as string followed by value",
+		[]Shape{nil, Circle{R: 0.5}, Square{Side: 300}})
+	return cs
+}
@@ -0,0 +1,85 @@
+package conformance_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+	"github.com/niubaoshu/gotiny/conformance"
+)
+
+func readSuite(t *testing.T) ([]byte, conformance.Suite) {
+	data, err := ioutil.ReadFile("testdata/v" + strconv.Itoa(conformance.Version) + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suite conformance.Suite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		t.Fatal(err)
+	}
+	if suite.Version != conformance.Version {
+		t.Fatalf("expected version %d, got %d", conformance.Version, suite.Version)
+	}
+	return data, suite
+}
+
+// TestVectors freezes wire format, vectors generated now should be the same as checked in
+func TestVectors(t *testing.T) {
+	data, suite := readSuite(t)
+	generated, err := conformance.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(data, generated) {
+		return
+	}
+	var current conformance.Suite
+	if err := json.Unmarshal(generated, &current); err != nil {
+		t.Fatal(err)
+	}
+	if len(current.Vectors) != len(suite.Vectors) {
+		t.Errorf("expected %d vectors, got %d", len(suite.Vectors), len(current.Vectors))
+	}
+	for i := 0; i < len(current.Vectors) && i < len(suite.Vectors); i++ {
+		if exp, got := suite.Vectors[i], current.Vectors[i]; exp.Name != got.Name || exp.Hex != got.Hex {
+			t.Errorf("%s: expected %s, got %s %s", exp.Name, exp.Hex, got.Name, got.Hex)
+		}
+	}
+	t.Error("vectors changed, incompatible changes of wire format need new version")
+}
+
+// TestVectorsDynamic checks vectors using only schemes of vectors
+func TestVectorsDynamic(t *testing.T) {
+	_, suite := readSuite(t)
+	for _, v := range suite.Vectors {
+		scheme, err := gotiny.SchemeFromJSON(string(v.Scheme))
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		buf, err := hex.DecodeString(v.Hex)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		value, err := gotiny.ToJSON(scheme, buf)
+		if err != nil {
+			t.Errorf("%s: %v", v.Name, err)
+		} else if !jsonEqual(value, v.Value) {
+			t.Errorf("%s: expected %s, got %s", v.Name, v.Value, value)
+		}
+		encoded, err := gotiny.FromJSON(scheme, v.Value)
+		if err != nil {
+			t.Errorf("%s: %v", v.Name, err)
+		} else if got := hex.EncodeToString(encoded); got != v.Hex {
+			t.Errorf("%s: expected %s, got %s", v.Name, v.Hex, got)
+		}
+	}
+}
+
+func jsonEqual(a, b []byte) bool {
+	var ca, cb bytes.Buffer
+	return json.Compact(&ca, a) == nil && json.Compact(&cb, b) == nil && bytes.Equal(ca.Bytes(), cb.Bytes())
+}
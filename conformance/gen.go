@@ -0,0 +1,24 @@
+//go:build ignore
+// +build ignore
+
+// gen writes vectors of current version into testdata
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"strconv"
+
+	"github.com/niubaoshu/gotiny/conformance"
+)
+
+func main() {
+	data, err := conformance.Generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	name := "testdata/v" + strconv.Itoa(conformance.Version) + ".json"
+	if err := ioutil.WriteFile(name, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -45,6 +45,11 @@ func (d *Decoder) decBool() (b bool) {
 	return
 }
 
+// rest returns not decoded data capped by its length, slicing it beyond data panics
+func (d *Decoder) rest() []byte {
+	return d.buf[d.index:len(d.buf):len(d.buf)]
+}
+
 func (d *Decoder) decUint64() uint64 {
 	buf, i := d.buf, d.index
 	x := uint64(buf[i])
@@ -174,7 +179,17 @@ func decComplex128(d *Decoder, p unsafe.Pointer) {
 
 func decString(d *Decoder, p unsafe.Pointer) {
 	l, val := int(d.decUint32()), (*string)(p)
-	*val = string(d.buf[d.index : d.index+l])
+	if d.zeroCopyStrings {
+		if b := d.rest()[:l]; l > 0 {
+			*val = unsafe.String(&b[0], l)
+		} else {
+			*val = ""
+		}
+	} else if d.interner != nil {
+		*val = d.interner.Intern(d.rest()[:l])
+	} else {
+		*val = string(d.buf[d.index : d.index+l])
+	}
 	d.index += l
 }
 
@@ -194,6 +209,7 @@ func skipUint32(d *Decoder, p unsafe.Pointer)     { d.decUint32() }
 func skipUint16(d *Decoder, p unsafe.Pointer)     { d.decUint16() }
 func skipByte(d *Decoder, p unsafe.Pointer)       { d.index++ }
 func skipComplex128(d *Decoder, p unsafe.Pointer) { d.decUint64(); d.decUint64() }
+func skipString(d *Decoder, p unsafe.Pointer)     { d.index += int(d.decUint32()) }
 func skipBytes(d *Decoder, p unsafe.Pointer) {
 	if d.decIsNotNil() {
 		l := int(d.decUint32())
@@ -13,6 +13,10 @@ type Decoder struct {
 
 	engines []decEng //解码器集合
 	length  int      //解码器数量
+
+	zeroCopyStrings bool           // strings alias buf instead of copying it
+	interner        StringInterner // returns decoded strings unless they are zero copy
+	allocator       *Allocator     // creates values of pointers and slices
 }
 
 // Unmarshal decodes any object from byte array
@@ -39,6 +43,25 @@ func NewDecoderWithType(ts ...reflect.Type) *Decoder {
 	return NewWithType(ts...).GetDecoder()
 }
 
+// SetZeroCopyStrings makes decoded strings alias decoded data like []byte values do, instead of copying it.
+// Strings are valid only while data isn't modified or reused: go assumes strings never change,
+// so data must stay immutable while any decoded string or its substring is used.
+func (d *Decoder) SetZeroCopyStrings(zeroCopy bool) {
+	d.zeroCopyStrings = zeroCopy
+}
+
+// SetInterner makes decoder take strings, including keys of maps, from interner, so repeated strings share memory.
+// Interner isn't used for zero copy strings, nil interner turns interning off.
+func (d *Decoder) SetInterner(interner StringInterner) {
+	d.interner = interner
+}
+
+// SetAllocator makes decoder create values of pointers and slices by allocator, nil allocator turns it off.
+// Decoder got from Coder has no allocator, decoder should be put back to Coder only when allocator isn't used any more.
+func (d *Decoder) SetAllocator(allocator *Allocator) {
+	d.allocator = allocator
+}
+
 func (d *Decoder) reset() int {
 	index := d.index
 	d.index = 0
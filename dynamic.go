@@ -0,0 +1,244 @@
+package gotiny
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// Value is value decoded by DecodeDynamic without its go type, it is one of
+//
+//	nil for nil pointers, slices, maps, interfaces and []byte
+//	bool
+//	int64 for int, int8, int16, int32 and int64
+//	uint64 for uint, uint8, uint16, uint32, uint64 and uintptr
+//	float64 for float32 and float64
+//	complex128 for complex64 and complex128
+//	string
+//	[]byte
+//	time.Time
+//	map[string]Value for structs
+//	[]Value for slices, arrays and top level values of coder scheme
+//	[]MapEntry for maps, keys of maps may be not comparable values
+//	InterfaceValue for interfaces
+//
+// Pointers are replaced by values they point to.
+// Values of types with custom encoding are decoded into their go types, which should be registered.
+type Value = interface{}
+
+// MapEntry is entry of map decoded by DecodeDynamic
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+// InterfaceValue is value of interface decoded by DecodeDynamic, Type is name type was registered with
+type InterfaceValue struct {
+	Type  string
+	Value Value
+}
+
+// DecodeDynamic decodes buf encoded with scheme without go types,
+// scheme of coder produces []Value with top level values
+func DecodeDynamic(scheme *Scheme, buf []byte) (v Value, err error) {
+	defer recoverDynamic(&err, "gotiny: corrupted data: %v")
+	d := &Decoder{buf: buf[:len(buf):len(buf)]} // capacity is cut, so reading after end of corrupted data fails
+	if scheme.isRoot() {
+		values := make([]Value, len(scheme.Childs))
+		for i, child := range scheme.Childs {
+			values[i] = d.decDynamic(child)
+		}
+		return values, nil
+	}
+	return d.decDynamic(scheme), nil
+}
+
+// dynamicError is panic of dynamic coding which is returned as is
+type dynamicError struct{ error }
+
+// recoverDynamic should be deferred, it sets err to recovered panic, other panics than dynamicError are formatted with format
+func recoverDynamic(err *error, format string) {
+	if r := recover(); r != nil {
+		if e, ok := r.(dynamicError); ok {
+			*err = e.error
+		} else {
+			*err = fmt.Errorf(format, r)
+		}
+	}
+}
+
+func (d *Decoder) decDynamic(s *Scheme) Value {
+	switch s.Type {
+	case typeIgnore:
+		if s.unknownKind() {
+			panic(dynamicError{s.unknownKindError()})
+		}
+		return nil
+	case typeBool:
+		return d.decBool()
+	case typeInt, typeInt64:
+		return uint64ToInt64(d.decUint64())
+	case typeInt8:
+		d.index++
+		return int64(int8(d.buf[d.index-1]))
+	case typeInt16:
+		return int64(uint16ToInt16(d.decUint16()))
+	case typeInt32:
+		return int64(uint32ToInt32(d.decUint32()))
+	case typeUint, typeUint64:
+		return d.decUint64()
+	case typeUint8:
+		d.index++
+		return uint64(d.buf[d.index-1])
+	case typeUint16:
+		return uint64(d.decUint16())
+	case typeUint32:
+		return uint64(d.decUint32())
+	case typeFloat32:
+		return float64(uint32ToFloat32(d.decUint32()))
+	case typeFloat64:
+		return uint64ToFloat64(d.decUint64())
+	case typeComplex64:
+		u := d.decUint64()
+		return complex128(*(*complex64)(unsafe.Pointer(&u)))
+	case typeComplex128:
+		re := math.Float64frombits(d.decUint64())
+		return complex(re, math.Float64frombits(d.decUint64()))
+	case typeString:
+		return d.decDynamicString()
+	case typeBytes:
+		if s.isStringNode() {
+			return d.decDynamicString()
+		}
+		if !d.decIsNotNil() {
+			return nil
+		}
+		l := d.decLength()
+		b := make([]byte, l)
+		d.index += copy(b, d.buf[d.index:d.index+l])
+		return b
+	case typeTime:
+		return time.Unix(0, int64(d.decUint64()))
+	case typeStruct:
+		fields := make(map[string]Value, len(s.Childs))
+		for _, child := range s.Childs {
+			fields[child.Name] = d.decDynamic(child)
+		}
+		return fields
+	case typePointer:
+		if !d.decIsNotNil() {
+			return nil
+		}
+		return d.decDynamic(s.child(0))
+	case typeArray:
+		values := make([]Value, s.arrayLen())
+		for i := range values {
+			values[i] = d.decDynamicElem(s)
+		}
+		return values
+	case typeSlice:
+		if !d.decIsNotNil() {
+			return nil
+		}
+		l := d.decLength()
+		values := make([]Value, 0, d.capacity(l))
+		for i := 0; i < l; i++ {
+			values = append(values, d.decDynamicElem(s))
+		}
+		return values
+	case typeMap:
+		if !d.decIsNotNil() {
+			return nil
+		}
+		l := d.decLength()
+		entries := make([]MapEntry, 0, d.capacity(l))
+		for i := 0; i < l; i++ {
+			key := d.decDynamic(s.child(0))
+			entries = append(entries, MapEntry{Key: key, Value: d.decDynamic(s.child(1))})
+		}
+		return entries
+	case typeInterface:
+		if !d.decIsNotNil() {
+			return nil
+		}
+		name := d.decDynamicString()
+		impl, ok := s.implScheme(name)
+		if !ok {
+			panic(dynamicError{errors.New("gotiny: type " + name + " of interface value is not registered")})
+		}
+		return InterfaceValue{Type: name, Value: d.decDynamic(impl)}
+	case typeCustom:
+		rt := s.customType()
+		if rt == nil {
+			panic(dynamicError{errors.New("gotiny: type " + s.GoType + " with custom encoding is not registered")})
+		}
+		v := reflect.New(rt)
+		schemeOfType(rt).decodeEngine(d, unsafe.Pointer(v.Pointer()))
+		return v.Elem().Interface()
+	}
+	panic(dynamicError{errors.New("gotiny: unknown scheme type " + s.Type.String())})
+}
+
+func (d *Decoder) decDynamicString() string {
+	var s string
+	decString(d, unsafe.Pointer(&s))
+	return s
+}
+
+// isStringNode reports whether node is encoded as string, it panics when it isn't known in scheme of previous version
+func (s *Scheme) isStringNode() bool {
+	if s.ambiguousBytes() {
+		panic(dynamicError{s.ambiguousBytesError()})
+	}
+	return s.Type == typeString || s.Type == typeBytes && s.legacy && s.GoType == "string"
+}
+
+// implScheme returns scheme of value of interface node s of type registered with name.
+// Types which aren't registered are looked up in children of s, idl unions keep schemes of their members there.
+func (s *Scheme) implScheme(name string) (*Scheme, bool) {
+	if rt, ok := typeByName(name); ok {
+		return schemeOfType(rt), true
+	}
+	for _, child := range s.Childs {
+		if child.GoType == name {
+			return child, true
+		}
+	}
+	return nil, false
+}
+
+// customType returns go type of node with custom encoding, nil if it isn't registered
+func (s *Scheme) customType() reflect.Type {
+	if s.rt != nil {
+		return s.rt
+	}
+	rt, _ := typeByName(s.GoType)
+	return rt
+}
+
+// arrayLen returns length of array node, it panics when length isn't known in scheme of previous version
+func (s *Scheme) arrayLen() int {
+	if s.unknownLen() {
+		panic(dynamicError{s.unknownLenError()})
+	}
+	return s.Len
+}
+
+// capacity limits preallocated capacity of l elements by length of remaining data
+func (d *Decoder) capacity(l int) int {
+	if rest := len(d.buf) - d.index; l > rest {
+		return rest
+	}
+	return l
+}
+
+// child returns i-th child of container node, which may be missing in corrupted scheme
+func (s *Scheme) child(i int) *Scheme {
+	if i >= len(s.Childs) {
+		panic(dynamicError{errors.New("gotiny: scheme node of type " + s.Type.String() + " has no child " + fmt.Sprint(i))})
+	}
+	return s.Childs[i]
+}
@@ -0,0 +1,291 @@
+package gotiny_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type dynamicT struct {
+	B    bool
+	I8   int8
+	I    int
+	U16  uint16
+	F32  float32
+	C64  complex64
+	S    string
+	Raw  []byte
+	T    time.Time
+	Arr  [2]uint8
+	Sl   []*int
+	M    map[string]int32
+	Nil  map[int]int
+	Any  interface{}
+	Tree *compatTree
+}
+
+func TestDecodeDynamic(t *testing.T) {
+	i := 5
+	src := dynamicT{
+		B: true, I8: -3, I: -1000, U16: 300, F32: 1.5, C64: complex(1, -2), S: "str", Raw: []byte{1, 2},
+		T: time.Unix(100, 5), Arr: [2]uint8{7, 8}, Sl: []*int{&i, nil}, M: map[string]int32{"a": -1},
+		Any: tint(4), Tree: &compatTree{Value: 1, Children: []*compatTree{{Value: 2}}},
+	}
+	coder := gotiny.New(dynamicT{}, "")
+	scheme, err := gotiny.SchemeFromJSON(coder.GetScheme().AsJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := "second"
+	got, err := gotiny.DecodeDynamic(scheme, coder.Encode(&src, &s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []gotiny.Value{map[string]gotiny.Value{
+		"B": true, "I8": int64(-3), "I": int64(-1000), "U16": uint64(300), "F32": 1.5, "C64": complex(1, -2),
+		"S": "str", "Raw": []byte{1, 2}, "T": time.Unix(100, 5), "Arr": []gotiny.Value{uint64(7), uint64(8)},
+		"Sl": []gotiny.Value{int64(5), nil}, "M": []gotiny.MapEntry{{Key: "a", Value: int64(-1)}}, "Nil": nil,
+		"Any": gotiny.InterfaceValue{Type: gotiny.GetName(tint(0)), Value: int64(4)},
+		"Tree": map[string]gotiny.Value{"Value": int64(1), "Children": []gotiny.Value{
+			map[string]gotiny.Value{"Value": int64(2), "Children": nil},
+		}},
+	}, "second"}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("expected\n%#v\ngot\n%#v", exp, got)
+	}
+}
+
+func TestDecodeDynamicErrors(t *testing.T) {
+	coder := gotiny.New([]string{})
+	data := coder.Encode(&[]string{"a", "b"})
+	if _, err := gotiny.DecodeDynamic(coder.GetScheme(), data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated data")
+	}
+
+	scheme, err := gotiny.SchemeFromJSON(`{"type":"interface"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gotiny.DecodeDynamic(scheme, []byte{1, 4, 'n', 'o', 'n', 'e'}); err == nil {
+		t.Error("expected error for value of unregistered type")
+	}
+}
+
+// TestDecodeDynamicLegacyArray decodes with scheme written before arrays kept their length,
+// scheme and data of struct{A [2]int; B int} are written by that version
+func TestDecodeDynamicLegacyArray(t *testing.T) {
+	legacyJSON := `{"childs":[{"type":1,"childs":[{"name":"A","type":3,"childs":[{"type":6}]},{"name":"B","type":6}]}]}`
+	data := []byte{2, 4, 6}
+	scheme, err := gotiny.SchemeFromJSON(legacyJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := gotiny.DecodeDynamic(scheme, data); err == nil || !strings.Contains(err.Error(), "length of array \"A\" isn't known") {
+		t.Errorf("expected error for unknown length, got %v %v", got, err)
+	}
+	if _, err := scheme.JSONSchema(); err == nil {
+		t.Error("expected error of json schema for unknown length")
+	}
+
+	scheme.Childs[0].Childs[0].Len = 2
+	got, err := gotiny.DecodeDynamic(scheme, data)
+	exp := []gotiny.Value{map[string]gotiny.Value{"A": []gotiny.Value{int64(1), int64(2)}, "B": int64(3)}}
+	if err != nil || !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v %v", exp, got, err)
+	}
+}
+
+// TestDecodeDynamicLegacyBytes decodes with scheme written when strings and []byte had the same type,
+// scheme and data of struct{B []byte; N int} are written by that version
+func TestDecodeDynamicLegacyBytes(t *testing.T) {
+	legacyJSON := `{"childs":[{"type":1,"childs":[{"name":"B","type":18},{"name":"N","type":6}]}]}`
+	data := []byte{1, 2, 'x', 'y', 14}
+	scheme, err := gotiny.SchemeFromJSON(legacyJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := gotiny.DecodeDynamic(scheme, data); err == nil || !strings.Contains(err.Error(), "either string or []byte") {
+		t.Errorf("expected error for ambiguous bytes, got %v %v", got, err)
+	}
+	if got, err := gotiny.ToJSON(scheme, data); err == nil {
+		t.Errorf("expected error for ambiguous bytes, got %s", got)
+	}
+	if _, err := scheme.JSONSchema(); err == nil {
+		t.Error("expected error of json schema for ambiguous bytes")
+	}
+
+	scheme.Childs[0].Childs[0].GoType = "[]byte"
+	got, err := gotiny.DecodeDynamic(scheme, data)
+	exp := []gotiny.Value{map[string]gotiny.Value{"B": []byte("xy"), "N": int64(7)}}
+	if err != nil || !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v %v", exp, got, err)
+	}
+	scheme.Childs[0].Childs[0].GoType = "string"
+	if got, err := gotiny.ToJSON(scheme, gotiny.Marshal(&struct {
+		S string
+		N int
+	}{"xy", 7})); err != nil || string(got) != `[{"B":"xy","N":7}]` {
+		t.Errorf("expected string, got %s %v", got, err)
+	}
+}
+
+func TestEncodeDynamic(t *testing.T) {
+	i := 5
+	src := dynamicT{
+		B: true, I8: -3, I: -1000, U16: 300, F32: 1.5, C64: complex(1, -2), S: "str", Raw: []byte{1, 2},
+		T: time.Unix(100, 5), Arr: [2]uint8{7, 8}, Sl: []*int{&i, nil}, M: map[string]int32{"a": -1},
+		Any: tint(4), Tree: &compatTree{Value: 1, Children: []*compatTree{{Value: 2}}},
+	}
+	coder := gotiny.New(dynamicT{})
+	exp := coder.Encode(&src)
+	value, err := gotiny.DecodeDynamic(coder.GetScheme(), exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gotiny.EncodeDynamic(coder.GetScheme(), value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("expected\n%v\ngot\n%v", exp, got)
+	}
+
+	// values of other types are coerced
+	fields := value.([]gotiny.Value)[0].(map[string]gotiny.Value)
+	fields["I8"], fields["I"], fields["U16"], fields["F32"] = -3, json.Number("-1000"), 300.0, float32(1.5)
+	fields["Raw"], fields["T"], fields["Arr"] = "\x01\x02", time.Unix(100, 5).Format(time.RFC3339Nano), []int{7, 8}
+	fields["Sl"], fields["M"], fields["Any"] = []interface{}{&i, nil}, map[string]int{"a": -1}, tint(4)
+	if got, err := gotiny.EncodeDynamic(coder.GetScheme(), value); err != nil || !reflect.DeepEqual(exp, got) {
+		t.Errorf("expected\n%v\ngot\n%v %v", exp, got, err)
+	}
+}
+
+func TestEncodeDynamicErrors(t *testing.T) {
+	scheme := gotiny.New(compatV1{}).GetScheme()
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"ID": 1, "Name": "n", "Old": 2, "Tags": nil, "Items": []interface{}{}, "Score": 3}
+	}
+	for _, c := range []struct {
+		change func(map[string]interface{})
+		err    string
+	}{
+		{func(m map[string]interface{}) {}, ""},
+		{func(m map[string]interface{}) { delete(m, "Name") }, "[0].Name: field is missing"},
+		{func(m map[string]interface{}) { m["Extra"] = 1 }, "[0]: field Extra is missing in scheme"},
+		{func(m map[string]interface{}) { m["ID"] = "1" }, "[0].ID: expected unsigned integer, got string"},
+		{func(m map[string]interface{}) { m["ID"] = -1 }, "[0].ID: -1 overflows uint32"},
+		{func(m map[string]interface{}) { m["Score"] = 1.5 }, "[0].Score: 1.5 overflows int32"},
+		{func(m map[string]interface{}) { m["Tags"] = []interface{}{"a", 2} }, "[0].Tags[1]: expected string, got int"},
+		{func(m map[string]interface{}) { m["Items"] = []interface{}{map[string]interface{}{}} }, "[0].Items[0].Price: field is missing"},
+	} {
+		m := valid()
+		c.change(m)
+		_, err := gotiny.EncodeDynamic(scheme, []interface{}{m})
+		if c.err == "" && err != nil || c.err != "" && (err == nil || err.Error() != "gotiny: "+c.err) {
+			t.Errorf("expected error %q, got %v", c.err, err)
+		}
+	}
+}
+
+type jsonT struct {
+	Big   int64
+	U     uint64
+	F     float32
+	Inf   float64
+	C     complex128
+	Raw   []byte
+	Empty []int
+	Nil   []int
+	T     time.Time
+	M     map[int8]string
+	SM    map[string]bool
+	Ptr   *int
+	Any   interface{}
+}
+
+func TestJSON(t *testing.T) {
+	src := jsonT{
+		Big: math.MaxInt64, U: math.MaxUint64, F: 0.1, Inf: math.Inf(-1), C: complex(1.5, -2), Raw: []byte("raw"),
+		Empty: []int{}, T: time.Unix(1, 5), M: map[int8]string{-1: "a"}, SM: map[string]bool{"k": true}, Any: tint(4),
+	}
+	coder := gotiny.New(jsonT{})
+	data := coder.Encode(&src)
+	got, err := gotiny.ToJSON(coder.GetScheme(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `[{"Big":9223372036854775807,"U":18446744073709551615,"F":0.1,"Inf":"-Inf","C":[1.5,-2],"Raw":"cmF3",` +
+		`"Empty":[],"Nil":null,"T":"1970-01-01T00:00:01.000000005Z","M":[[-1,"a"]],"SM":{"k":true},"Ptr":null,` +
+		`"Any":{"type":"` + gotiny.GetName(tint(0)) + `","value":4}}]`
+	if string(got) != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, got)
+	}
+	back, err := gotiny.FromJSON(coder.GetScheme(), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, back) {
+		t.Errorf("expected\n%v\ngot\n%v", data, back)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	coder := gotiny.New(jsonT{})
+	valid, err := gotiny.ToJSON(coder.GetScheme(), coder.Encode(&jsonT{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		old, new, err string
+	}{
+		{"}]", "}] 1", "gotiny: unexpected data after JSON value"},
+		{`[{"Big":0`, `[{"Big":0}, {"Big":0`, "gotiny: expected array of 1 values, got array"},
+		{`"U":0`, `"U":-1`, "gotiny: [0].U: expected unsigned integer, got -1"},
+		{`"Raw":null`, `"Raw":"?"`, "gotiny: [0].Raw: expected base64 string: illegal base64 data at input byte 0"},
+		{`"M":null`, `"M":{"1":"a"}`, "gotiny: [0].M: expected array of [key, value] pairs, got object"},
+		{`"Any":null`, `"Any":{"type":"none","value":1}`, "gotiny: [0].Any: type none is not registered"},
+	} {
+		data := strings.Replace(string(valid), c.old, c.new, 1)
+		if _, err := gotiny.FromJSON(coder.GetScheme(), []byte(data)); err == nil || err.Error() != c.err {
+			t.Errorf("%s: expected error %q, got %v", data, c.err, err)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	type annotated struct {
+		A, B bool
+		S    []string
+		P    *int
+		I    int16
+	}
+	coder := gotiny.New(annotated{})
+	data := coder.Encode(&annotated{A: true, S: []string{"x"}, I: -2})
+	got, err := gotiny.Annotate(coder.GetScheme(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []gotiny.Annotation{
+		{Path: "[0].A", What: "bool", Offset: 0, Len: 1, Bit: 0, Value: true},
+		{Path: "[0].B", What: "bool", Offset: 0, Len: 0, Bit: 1, Value: false},
+		{Path: "[0].S", What: "not nil", Offset: 0, Len: 0, Bit: 2, Value: true},
+		{Path: "[0].S", What: "length", Offset: 1, Len: 1, Bit: -1, Value: 1},
+		{Path: "[0].S[0]", What: "string", Offset: 2, Len: 2, Bit: -1, Value: "x"},
+		{Path: "[0].P", What: "not nil", Offset: 0, Len: 0, Bit: 3, Value: false},
+		{Path: "[0].I", What: "int16", Offset: 4, Len: 1, Bit: -1, Value: int64(-2)},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("expected\n%+v\ngot\n%+v", exp, got)
+	}
+
+	got, err = gotiny.Annotate(coder.GetScheme(), data[:3])
+	if err == nil || !reflect.DeepEqual(exp[:4], got) {
+		t.Errorf("expected\n%+v\nwith error, got\n%+v %v", exp[:4], got, err)
+	}
+}
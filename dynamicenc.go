@@ -0,0 +1,360 @@
+package gotiny
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// EncodeDynamic encodes v without go types, bytes are the same as written by coder with scheme.
+// v has the same form as values returned by DecodeDynamic, but types are coerced:
+// numbers may be of any go numeric type or json.Number if value fits into type of scheme,
+// structs may be maps with string keys, slices, arrays and maps may be of any go type,
+// []byte may be string, time may be RFC 3339 string or unix time in nanoseconds,
+// interfaces may be InterfaceValue or value of registered go type.
+// Every field of struct should be present and fields missing in scheme are not allowed.
+func EncodeDynamic(scheme *Scheme, v interface{}) (buf []byte, err error) {
+	defer recoverDynamic(&err, "gotiny: %v")
+	e := &Encoder{}
+	if scheme.isRoot() {
+		values := reflect.ValueOf(v)
+		if k := values.Kind(); k != reflect.Slice && k != reflect.Array || values.Len() != len(scheme.Childs) {
+			return nil, fmt.Errorf("gotiny: expected list of %d values, got %T", len(scheme.Childs), v)
+		}
+		for i, child := range scheme.Childs {
+			e.encDynamic(child, values.Index(i).Interface(), "["+strconv.Itoa(i)+"]")
+		}
+		return e.buf, nil
+	}
+	e.encDynamic(scheme, v, "")
+	return e.buf, nil
+}
+
+func dynamicErrorf(path, format string, args ...interface{}) dynamicError {
+	if path == "" {
+		path = "value"
+	}
+	return dynamicError{fmt.Errorf("gotiny: %s: %s", path, fmt.Sprintf(format, args...))}
+}
+
+func (e *Encoder) encDynamic(s *Scheme, v interface{}, path string) {
+	switch s.Type {
+	case typeIgnore:
+		if s.unknownKind() {
+			panic(dynamicError{s.unknownKindError()})
+		}
+	case typeBool:
+		b, ok := v.(bool)
+		if !ok {
+			panic(dynamicErrorf(path, "expected bool, got %T", v))
+		}
+		e.encBool(b)
+	case typeInt, typeInt64:
+		e.encUint64(int64ToUint64(dynamicInt(v, 64, path)))
+	case typeInt8:
+		e.buf = append(e.buf, byte(dynamicInt(v, 8, path)))
+	case typeInt16:
+		e.encUint16(int16ToUint16(int16(dynamicInt(v, 16, path))))
+	case typeInt32:
+		e.encUint32(int32ToUint32(int32(dynamicInt(v, 32, path))))
+	case typeUint, typeUint64:
+		e.encUint64(dynamicUint(v, 64, path))
+	case typeUint8:
+		e.buf = append(e.buf, byte(dynamicUint(v, 8, path)))
+	case typeUint16:
+		e.encUint16(uint16(dynamicUint(v, 16, path)))
+	case typeUint32:
+		e.encUint32(uint32(dynamicUint(v, 32, path)))
+	case typeFloat32:
+		f := float32(dynamicFloat(v, path))
+		e.encUint32(float32ToUint32(unsafe.Pointer(&f)))
+	case typeFloat64:
+		f := dynamicFloat(v, path)
+		e.encUint64(float64ToUint64(unsafe.Pointer(&f)))
+	case typeComplex64:
+		c := complex64(dynamicComplex(v, path))
+		e.encUint64(*(*uint64)(unsafe.Pointer(&c)))
+	case typeComplex128:
+		c := dynamicComplex(v, path)
+		e.encUint64(math.Float64bits(real(c)))
+		e.encUint64(math.Float64bits(imag(c)))
+	case typeString:
+		e.encString(dynamicString(v, path))
+	case typeBytes:
+		if s.isStringNode() {
+			e.encString(dynamicString(v, path))
+			return
+		}
+		switch b := v.(type) {
+		case nil:
+			e.encIsNotNil(false)
+		case []byte:
+			e.encIsNotNil(b != nil)
+			if b != nil {
+				e.encLength(len(b))
+				e.buf = append(e.buf, b...)
+			}
+		case string:
+			e.encIsNotNil(true)
+			e.encString(b)
+		default:
+			panic(dynamicErrorf(path, "expected []byte, got %T", v))
+		}
+	case typeTime:
+		e.encUint64(uint64(dynamicTime(v, path).UnixNano()))
+	case typeStruct:
+		e.encDynamicStruct(s, v, path)
+	case typePointer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+			if v = nil; !rv.IsNil() {
+				v = rv.Elem().Interface()
+			}
+		}
+		e.encIsNotNil(v != nil)
+		if v != nil {
+			e.encDynamic(s.child(0), v, path)
+		}
+	case typeArray:
+		rv := dynamicList(v, path)
+		l := s.arrayLen()
+		if !rv.IsValid() || rv.Len() != l {
+			panic(dynamicErrorf(path, "expected %d elements, got %T", l, v))
+		}
+		for i := 0; i < l; i++ {
+			e.encDynamicElem(s, rv.Index(i).Interface(), path+"["+strconv.Itoa(i)+"]")
+		}
+	case typeSlice:
+		rv := dynamicList(v, path)
+		notNil := rv.IsValid() && (rv.Kind() == reflect.Array || !rv.IsNil())
+		e.encIsNotNil(notNil)
+		if notNil {
+			e.encLength(rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				e.encDynamicElem(s, rv.Index(i).Interface(), path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case typeMap:
+		e.encDynamicMap(s, v, path)
+	case typeInterface:
+		e.encIsNotNil(v != nil)
+		if v == nil {
+			return
+		}
+		if iv, ok := v.(InterfaceValue); ok {
+			impl, ok := s.implScheme(iv.Type)
+			if !ok {
+				panic(dynamicErrorf(path, "type %s is not registered", iv.Type))
+			}
+			e.encString(iv.Type)
+			e.encDynamic(impl, iv.Value, path)
+			return
+		}
+		rt := reflect.TypeOf(v)
+		e.encString(getNameOfType(rt))
+		e.encTyped(rt, v)
+	case typeCustom:
+		rt := s.customType()
+		if rt == nil || reflect.TypeOf(v) != rt {
+			panic(dynamicErrorf(path, "expected value of type %s with custom encoding, got %T", s.GoType, v))
+		}
+		e.encTyped(rt, v)
+	default:
+		panic(dynamicErrorf(path, "unknown scheme type %s", s.Type))
+	}
+}
+
+// encTyped encodes v of type rt with its engine
+func (e *Encoder) encTyped(rt reflect.Type, v interface{}) {
+	p := reflect.New(rt)
+	p.Elem().Set(reflect.ValueOf(v))
+	schemeOfType(rt).encodeEngine(e, unsafe.Pointer(p.Pointer()))
+}
+
+func (e *Encoder) encDynamicStruct(s *Scheme, v interface{}, path string) {
+	fields := reflect.ValueOf(v)
+	if fields.Kind() != reflect.Map || fields.Type().Key().Kind() != reflect.String {
+		panic(dynamicErrorf(path, "expected map with field names as keys, got %T", v))
+	}
+	if fields.Len() > len(s.Childs) {
+		known := make(map[string]bool, len(s.Childs))
+		for _, child := range s.Childs {
+			known[child.Name] = true
+		}
+		for _, key := range fields.MapKeys() {
+			if !known[key.String()] {
+				panic(dynamicErrorf(path, "field %s is missing in scheme", key.String()))
+			}
+		}
+	}
+	for _, child := range s.Childs {
+		fieldPath := child.Name
+		if path != "" {
+			fieldPath = path + "." + child.Name
+		}
+		field := fields.MapIndex(reflect.ValueOf(child.Name).Convert(fields.Type().Key()))
+		if !field.IsValid() {
+			panic(dynamicErrorf(fieldPath, "field is missing"))
+		}
+		e.encDynamic(child, field.Interface(), fieldPath)
+	}
+}
+
+func (e *Encoder) encDynamicMap(s *Scheme, v interface{}, path string) {
+	if entries, ok := v.([]MapEntry); ok {
+		e.encIsNotNil(entries != nil)
+		if entries != nil {
+			e.encLength(len(entries))
+			for _, entry := range entries {
+				e.encDynamic(s.child(0), entry.Key, path+"{key}")
+				e.encDynamic(s.child(1), entry.Value, path+"["+fmt.Sprint(entry.Key)+"]")
+			}
+		}
+		return
+	}
+	rv := reflect.ValueOf(v)
+	if v != nil && rv.Kind() != reflect.Map {
+		panic(dynamicErrorf(path, "expected map, got %T", v))
+	}
+	notNil := v != nil && !rv.IsNil()
+	e.encIsNotNil(notNil)
+	if notNil {
+		e.encLength(rv.Len())
+		// keys are sorted to have the same bytes for the same maps
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			e.encDynamic(s.child(0), key.Interface(), path+"{key}")
+			e.encDynamic(s.child(1), rv.MapIndex(key).Interface(), path+"["+fmt.Sprint(key)+"]")
+		}
+	}
+}
+
+// dynamicList returns slice or array v, invalid value for nil
+func dynamicList(v interface{}, path string) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if v != nil && rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		panic(dynamicErrorf(path, "expected list, got %T", v))
+	}
+	return rv
+}
+
+func dynamicInt(v interface{}, bits uint, path string) int64 {
+	var i int64
+	ok := true
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		i, ok = int64(u), u <= math.MaxInt64
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		i, ok = int64(f), f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+	case reflect.String:
+		n, isNumber := v.(json.Number)
+		if !isNumber {
+			panic(dynamicErrorf(path, "expected integer, got %T", v))
+		}
+		var err error
+		if i, err = n.Int64(); err != nil {
+			panic(dynamicErrorf(path, "expected integer, got %s", n))
+		}
+	default:
+		panic(dynamicErrorf(path, "expected integer, got %T", v))
+	}
+	if min, max := int64(-1)<<(bits-1), int64(1<<(bits-1)-1); !ok || i < min || i > max {
+		panic(dynamicErrorf(path, "%v overflows int%d", v, bits))
+	}
+	return i
+}
+
+func dynamicUint(v interface{}, bits uint, path string) uint64 {
+	var u uint64
+	ok := true
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		u, ok = uint64(i), i >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u = rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		u, ok = uint64(f), f == math.Trunc(f) && f >= 0 && f < math.MaxUint64
+	case reflect.String:
+		n, isNumber := v.(json.Number)
+		if !isNumber {
+			panic(dynamicErrorf(path, "expected unsigned integer, got %T", v))
+		}
+		var err error
+		if u, err = strconv.ParseUint(string(n), 10, 64); err != nil {
+			panic(dynamicErrorf(path, "expected unsigned integer, got %s", n))
+		}
+	default:
+		panic(dynamicErrorf(path, "expected unsigned integer, got %T", v))
+	}
+	if !ok || bits < 64 && u >= 1<<bits {
+		panic(dynamicErrorf(path, "%v overflows uint%d", v, bits))
+	}
+	return u
+}
+
+func dynamicFloat(v interface{}, path string) float64 {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				return f
+			}
+		}
+	}
+	panic(dynamicErrorf(path, "expected number, got %T", v))
+}
+
+func dynamicComplex(v interface{}, path string) complex128 {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Complex64 || rv.Kind() == reflect.Complex128 {
+		return rv.Complex()
+	}
+	return complex(dynamicFloat(v, path), 0)
+}
+
+func dynamicString(v interface{}, path string) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	panic(dynamicErrorf(path, "expected string, got %T", v))
+}
+
+func dynamicTime(v interface{}, path string) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			panic(dynamicErrorf(path, "%v", err))
+		}
+		return parsed
+	case json.Number:
+		return time.Unix(0, dynamicInt(v, 64, path))
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32, reflect.Float64:
+		return time.Unix(0, dynamicInt(v, 64, path))
+	}
+	panic(dynamicErrorf(path, "expected time, got %T", v))
+}
@@ -0,0 +1,357 @@
+package gotiny
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ToJSON converts buf encoded with scheme into JSON without go types.
+// Integers are written exactly, floats NaN and ±Inf as strings "NaN", "+Inf" and "-Inf",
+// complex numbers as [real, imag], []byte as base64 string, time as RFC 3339 string in UTC,
+// nil pointers, slices, maps and []byte as null, so they differ from empty ones,
+// but pointer to nil value is written as null too.
+// Structs are objects with fields in scheme order, maps with string keys are objects with sorted keys,
+// other maps are arrays of [key, value] pairs, interfaces are objects {"type": name, "value": value}.
+// Values of types with custom encoding are written by encoding/json, their types should be registered.
+// Scheme of coder produces array of top level values.
+func ToJSON(scheme *Scheme, buf []byte) (data []byte, err error) {
+	v, err := DecodeDynamic(scheme, buf)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverDynamic(&err, "gotiny: %v")
+	w := &bytes.Buffer{}
+	if scheme.isRoot() {
+		values := v.([]Value)
+		w.WriteByte('[')
+		for i, child := range scheme.Childs {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeJSON(w, child, values[i])
+		}
+		w.WriteByte(']')
+	} else {
+		writeJSON(w, scheme, v)
+	}
+	return w.Bytes(), nil
+}
+
+// FromJSON converts JSON written by ToJSON into bytes encoded with scheme,
+// so data can be edited by hand. Numbers keep their precision and are checked to fit into types of scheme.
+func FromJSON(scheme *Scheme, data []byte) (buf []byte, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("gotiny: unexpected data after JSON value")
+	}
+	defer recoverDynamic(&err, "gotiny: %v")
+	if scheme.isRoot() {
+		values, ok := v.([]interface{})
+		if !ok || len(values) != len(scheme.Childs) {
+			return nil, fmt.Errorf("gotiny: expected array of %d values, got %s", len(scheme.Childs), jsonKind(v))
+		}
+		for i, child := range scheme.Childs {
+			values[i] = fromJSON(child, values[i], "["+strconv.Itoa(i)+"]")
+		}
+		v = values
+	} else {
+		v = fromJSON(scheme, v, "")
+	}
+	return EncodeDynamic(scheme, v)
+}
+
+func writeJSON(w *bytes.Buffer, s *Scheme, v Value) {
+	if v == nil {
+		w.WriteString("null")
+		return
+	}
+	switch s.Type {
+	case typeIgnore:
+		w.WriteString("null")
+	case typeBool:
+		w.WriteString(strconv.FormatBool(v.(bool)))
+	case typeInt, typeInt8, typeInt16, typeInt32, typeInt64:
+		w.WriteString(strconv.FormatInt(v.(int64), 10))
+	case typeUint, typeUint8, typeUint16, typeUint32, typeUint64:
+		w.WriteString(strconv.FormatUint(v.(uint64), 10))
+	case typeFloat32:
+		writeJSONFloat(w, v.(float64), 32)
+	case typeFloat64:
+		writeJSONFloat(w, v.(float64), 64)
+	case typeComplex64, typeComplex128:
+		bits := 64
+		if s.Type == typeComplex64 {
+			bits = 32
+		}
+		c := v.(complex128)
+		w.WriteByte('[')
+		writeJSONFloat(w, real(c), bits)
+		w.WriteByte(',')
+		writeJSONFloat(w, imag(c), bits)
+		w.WriteByte(']')
+	case typeString, typeBytes:
+		if b, ok := v.([]byte); ok {
+			v = base64.StdEncoding.EncodeToString(b)
+		}
+		writeJSONString(w, v.(string))
+	case typeTime:
+		writeJSONString(w, v.(time.Time).UTC().Format(time.RFC3339Nano))
+	case typeStruct:
+		fields := v.(map[string]Value)
+		w.WriteByte('{')
+		for i, child := range s.Childs {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeJSONString(w, child.Name)
+			w.WriteByte(':')
+			writeJSON(w, child, fields[child.Name])
+		}
+		w.WriteByte('}')
+	case typePointer:
+		writeJSON(w, s.child(0), v)
+	case typeArray, typeSlice:
+		w.WriteByte('[')
+		for i, elem := range v.([]Value) {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeJSON(w, s.child(0), elem)
+		}
+		w.WriteByte(']')
+	case typeMap:
+		writeJSONMap(w, s, v.([]MapEntry))
+	case typeInterface:
+		iv := v.(InterfaceValue)
+		w.WriteString(`{"type":`)
+		writeJSONString(w, iv.Type)
+		w.WriteString(`,"value":`)
+		impl, _ := s.implScheme(iv.Type)
+		writeJSON(w, impl, iv.Value)
+		w.WriteByte('}')
+	case typeCustom:
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(dynamicError{err})
+		}
+		w.Write(data)
+	default:
+		panic(dynamicErrorf(s.Name, "unknown scheme type %s", s.Type))
+	}
+}
+
+// writeJSONMap writes entries sorted by keys, so the same maps have the same JSON
+func writeJSONMap(w *bytes.Buffer, s *Scheme, entries []MapEntry) {
+	type pair struct{ key, value []byte }
+	pairs := make([]pair, len(entries))
+	for i, entry := range entries {
+		var key, value bytes.Buffer
+		writeJSON(&key, s.child(0), entry.Key)
+		writeJSON(&value, s.child(1), entry.Value)
+		pairs[i] = pair{key.Bytes(), value.Bytes()}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return bytes.Compare(pairs[i].key, pairs[j].key) < 0 })
+
+	stringKeys := s.child(0).isStringNode()
+	if stringKeys {
+		w.WriteByte('{')
+	} else {
+		w.WriteByte('[')
+	}
+	for i, p := range pairs {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if stringKeys {
+			w.Write(p.key)
+			w.WriteByte(':')
+			w.Write(p.value)
+		} else {
+			w.WriteByte('[')
+			w.Write(p.key)
+			w.WriteByte(',')
+			w.Write(p.value)
+			w.WriteByte(']')
+		}
+	}
+	if stringKeys {
+		w.WriteByte('}')
+	} else {
+		w.WriteByte(']')
+	}
+}
+
+func writeJSONFloat(w *bytes.Buffer, f float64, bits int) {
+	switch {
+	case math.IsNaN(f):
+		w.WriteString(`"NaN"`)
+	case math.IsInf(f, 1):
+		w.WriteString(`"+Inf"`)
+	case math.IsInf(f, -1):
+		w.WriteString(`"-Inf"`)
+	default:
+		w.WriteString(strconv.FormatFloat(f, 'g', -1, bits))
+	}
+}
+
+func writeJSONString(w *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s)
+	w.Write(data)
+}
+
+// fromJSON converts v decoded by encoding/json into value accepted by EncodeDynamic
+func fromJSON(s *Scheme, v interface{}, path string) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch s.Type {
+	case typeFloat32, typeFloat64:
+		return jsonFloat(v, path)
+	case typeComplex64, typeComplex128:
+		parts, ok := v.([]interface{})
+		if !ok || len(parts) != 2 {
+			panic(dynamicErrorf(path, "expected [real, imag], got %s", jsonKind(v)))
+		}
+		return complex(dynamicFloat(jsonFloat(parts[0], path), path), dynamicFloat(jsonFloat(parts[1], path), path))
+	case typeBytes:
+		str, ok := v.(string)
+		if !ok || s.isStringNode() {
+			return v
+		}
+		b, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			panic(dynamicErrorf(path, "expected base64 string: %v", err))
+		}
+		return b
+	case typeStruct:
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			panic(dynamicErrorf(path, "expected object, got %s", jsonKind(v)))
+		}
+		for _, child := range s.Childs {
+			if field, ok := fields[child.Name]; ok {
+				fieldPath := child.Name
+				if path != "" {
+					fieldPath = path + "." + child.Name
+				}
+				fields[child.Name] = fromJSON(child, field, fieldPath)
+			}
+		}
+		return fields
+	case typePointer:
+		return fromJSON(s.child(0), v, path)
+	case typeArray, typeSlice:
+		elems, ok := v.([]interface{})
+		if !ok {
+			panic(dynamicErrorf(path, "expected array, got %s", jsonKind(v)))
+		}
+		for i, elem := range elems {
+			elems[i] = fromJSON(s.child(0), elem, path+"["+strconv.Itoa(i)+"]")
+		}
+		return elems
+	case typeMap:
+		return fromJSONMap(s, v, path)
+	case typeInterface:
+		obj, _ := v.(map[string]interface{})
+		name, ok := obj["type"].(string)
+		if !ok || len(obj) != 2 {
+			panic(dynamicErrorf(path, `expected object {"type": name, "value": value}, got %s`, jsonKind(v)))
+		}
+		impl, ok := s.implScheme(name)
+		if !ok {
+			panic(dynamicErrorf(path, "type %s is not registered", name))
+		}
+		return InterfaceValue{Type: name, Value: fromJSON(impl, obj["value"], path)}
+	case typeCustom:
+		rt := s.customType()
+		if rt == nil {
+			panic(dynamicErrorf(path, "type %s with custom encoding is not registered", s.GoType))
+		}
+		data, err := json.Marshal(v)
+		if err == nil {
+			p := reflect.New(rt)
+			if err = json.Unmarshal(data, p.Interface()); err == nil {
+				return p.Elem().Interface()
+			}
+		}
+		panic(dynamicErrorf(path, "%v", err))
+	}
+	return v
+}
+
+func fromJSONMap(s *Scheme, v interface{}, path string) []MapEntry {
+	var entries []MapEntry
+	switch m := v.(type) {
+	case map[string]interface{}:
+		if !s.child(0).isStringNode() {
+			panic(dynamicErrorf(path, "expected array of [key, value] pairs, got object"))
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries = make([]MapEntry, len(keys))
+		for i, key := range keys {
+			entries[i] = MapEntry{Key: key, Value: fromJSON(s.child(1), m[key], path+"["+key+"]")}
+		}
+	case []interface{}:
+		entries = make([]MapEntry, len(m))
+		for i, pair := range m {
+			kv, ok := pair.([]interface{})
+			if !ok || len(kv) != 2 {
+				panic(dynamicErrorf(path+"["+strconv.Itoa(i)+"]", "expected [key, value] pair, got %s", jsonKind(pair)))
+			}
+			key := fromJSON(s.child(0), kv[0], path+"{key}")
+			entries[i] = MapEntry{Key: key, Value: fromJSON(s.child(1), kv[1], path+"["+fmt.Sprint(key)+"]")}
+		}
+	default:
+		panic(dynamicErrorf(path, "expected map, got %s", jsonKind(v)))
+	}
+	return entries
+}
+
+// jsonFloat parses NaN and ±Inf written as strings
+func jsonFloat(v interface{}, path string) interface{} {
+	str, ok := v.(string)
+	if !ok {
+		return v
+	}
+	switch str {
+	case "NaN", "+Inf", "-Inf":
+		f, _ := strconv.ParseFloat(str, 64)
+		return f
+	}
+	panic(dynamicErrorf(path, "expected number, got string %q", str))
+}
+
+// jsonKind returns name of JSON type of v decoded by encoding/json
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
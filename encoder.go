@@ -1,6 +1,7 @@
 package gotiny
 
 import (
+	"io"
 	"reflect"
 	"unsafe"
 )
@@ -13,8 +14,10 @@ type Encoder struct {
 	boolPos int  // The next time you want to set the subscript of bool in buf, buf[boolPos]
 	boolBit byte // The bit in the buf[boolPos] of the bool to be set next time.
 
-	engines []encEng
-	length  int
+	engines     []encEng
+	sizeEngines []sizeEng
+	length      int
+	size        int // length of data counted by size engines
 }
 
 // Marshal instantly encodes any object by pointer to byte array
@@ -38,7 +41,8 @@ func NewEncoderWithType(ts ...reflect.Type) *Encoder {
 	return NewWithType(ts...).GetEncoder()
 }
 
-// Encode object using entry parameter as a pointer to the value to be encoded
+// Encode object using entry parameter as a pointer to the value to be encoded.
+// Returned data is buffer of encoder, it's overwritten by next encoding, copy it or use AppendEncode to keep it.
 func (e *Encoder) Encode(is ...interface{}) []byte {
 	engines := e.engines
 	for i := 0; i < len(engines) && i < len(is); i++ {
@@ -65,6 +69,43 @@ func (e *Encoder) EncodeValue(vs ...reflect.Value) []byte {
 	return e.reset()
 }
 
+// AppendEncode appends data of values to dst and returns extended slice like append does,
+// buffer of encoder isn't used, so data belongs to caller.
+func (e *Encoder) AppendEncode(dst []byte, is ...interface{}) []byte {
+	buf, off := e.buf, e.off
+	e.buf, e.off = dst, len(dst)
+	dst = e.Encode(is...)
+	e.buf, e.off = buf, off
+	return dst
+}
+
+// EncodeInto writes data of values to beginning of buf and returns its length, buf is never grown.
+// When data doesn't fit into len(buf), it returns io.ErrShortBuffer with required length and buf is left untouched,
+// unless serializer of some value writes more than it did when size was counted.
+func (e *Encoder) EncodeInto(buf []byte, is ...interface{}) (int, error) {
+	if n := e.Size(is...); n > len(buf) {
+		return n, io.ErrShortBuffer
+	}
+	data := e.AppendEncode(buf[:0:len(buf)], is...)
+	if len(data) > len(buf) {
+		return len(data), io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Size returns length of data Encode would return for the same values, data isn't written.
+// Values of types with their own serializers are encoded to find their length.
+func (e *Encoder) Size(is ...interface{}) int {
+	engines := e.sizeEngines
+	for i := 0; i < len(engines) && i < len(is); i++ {
+		engines[i](e, (*[2]unsafe.Pointer)(unsafe.Pointer(&is[i]))[1])
+	}
+	n := e.size
+	e.size = 0
+	e.boolBit = 0
+	return n
+}
+
 // AppendTo the data generated by the encoding will be append to buf
 func (e *Encoder) AppendTo(buf []byte) {
 	e.off = len(buf)
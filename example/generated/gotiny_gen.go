@@ -0,0 +1,630 @@
+// Code generated by gotinygen. DO NOT EDIT.
+
+package generated
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// GotinyEncode appends encoded value to buf, it implements gotiny.GoTinySerializer.
+func (v *Record) GotinyEncode(buf []byte) []byte {
+	var boolPos int
+	var boolBit byte
+	return v.GotinyEncodeBools(buf, &boolPos, &boolBit)
+}
+
+// GotinyEncodeBools appends encoded value to buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.
+func (v *Record) GotinyEncodeBools(buf []byte, boolPos *int, boolBit *byte) []byte {
+	e := &gotinyEncoder{buf: buf, boolPos: *boolPos, boolBit: *boolBit}
+	e.uint64(v.ID)
+	e.string(v.Name)
+	e.bool(v.Active)
+	for i1 := range v.Flags {
+		e.bool(v.Flags[i1])
+	}
+	e.uint64(bits.ReverseBytes64(math.Float64bits(v.Score)))
+	e.uint32(bits.ReverseBytes32(math.Float32bits(v.Ratio)))
+	e.int32(v.Delta)
+	e.buf = append(e.buf, byte(v.Small))
+	e.uint16(v.Count)
+	e.uint64(uint64(math.Float32bits(real(v.Phase))) | uint64(math.Float32bits(imag(v.Phase)))<<32)
+	e.uint64(math.Float64bits(real(v.Wave)))
+	e.uint64(math.Float64bits(imag(v.Wave)))
+	e.uint64(uint64(v.Created.UnixNano()))
+	e.bytes(v.Data)
+	gotinyEncodeBlob(e, &v.Raw)
+	e.bool(v.Tags != nil)
+	if v.Tags != nil {
+		e.length(len(v.Tags))
+		for i2 := range v.Tags {
+			e.string(v.Tags[i2])
+		}
+	}
+	e.bool(v.Attrs != nil)
+	if v.Attrs != nil {
+		e.length(len(v.Attrs))
+		for k3, v4 := range v.Attrs {
+			e.string(k3)
+			e.int64(int64(v4))
+		}
+	}
+	e.bool(v.Set != nil)
+	if v.Set != nil {
+		e.length(len(v.Set))
+		for k5 := range v.Set {
+			e.int16(k5)
+		}
+	}
+	e.bool(v.Parent != nil)
+	if v.Parent != nil {
+		e.buf = v.Parent.GotinyEncodeBools(e.buf, &e.boolPos, &e.boolBit)
+	}
+	e.bool(v.Children != nil)
+	if v.Children != nil {
+		e.length(len(v.Children))
+		for i7 := range v.Children {
+			e.bool(v.Children[i7] != nil)
+			if v.Children[i7] != nil {
+				e.buf = v.Children[i7].GotinyEncodeBools(e.buf, &e.boolPos, &e.boolBit)
+			}
+		}
+	}
+	gotinyEncodeStatus(e, &v.Status)
+	gotinyEncodePoint(e, &v.Point)
+	e.bool(v.Version != nil)
+	if v.Version != nil {
+		{
+			b, err := v.Version.MarshalBinary()
+			if err != nil {
+				panic(err)
+			}
+			e.length(len(b))
+			e.buf = append(e.buf, b...)
+		}
+	}
+	e.buf = v.Matrix.GotinyEncodeBools(e.buf, &e.boolPos, &e.boolBit)
+	gotinyEncodeinner(e, &v.inner)
+	*boolPos, *boolBit = e.boolPos, e.boolBit
+	return e.buf
+}
+
+// GotinyDecode decodes value from buf and returns number of bytes read, it implements gotiny.GoTinySerializer.
+func (v *Record) GotinyDecode(buf []byte) int {
+	var bools, boolBit byte
+	return v.GotinyDecodeBools(buf, &bools, &boolBit)
+}
+
+// GotinyDecodeBools decodes value from buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.
+func (v *Record) GotinyDecodeBools(buf []byte, bools, boolBit *byte) int {
+	d := &gotinyDecoder{buf: buf, boolPos: *bools, boolBit: *boolBit}
+	v.ID = d.uint64()
+	v.Name = d.string()
+	v.Active = d.bool()
+	for i1 := range v.Flags {
+		v.Flags[i1] = d.bool()
+	}
+	v.Score = math.Float64frombits(bits.ReverseBytes64(d.uint64()))
+	v.Ratio = math.Float32frombits(bits.ReverseBytes32(d.uint32()))
+	v.Delta = d.int32()
+	v.Small = int8(d.byte())
+	v.Count = d.uint16()
+	{
+		u2 := d.uint64()
+		v.Phase = complex(math.Float32frombits(uint32(u2)), math.Float32frombits(uint32(u2>>32)))
+	}
+	{
+		re3 := math.Float64frombits(d.uint64())
+		v.Wave = complex(re3, math.Float64frombits(d.uint64()))
+	}
+	v.Created = time.Unix(0, int64(d.uint64()))
+	v.Data = d.bytes()
+	gotinyDecodeBlob(d, &v.Raw)
+	if d.bool() {
+		l4 := d.length()
+		if v.Tags == nil || cap(v.Tags) < l4 {
+			v.Tags = make([]string, l4)
+		} else {
+			v.Tags = v.Tags[:l4]
+		}
+		for i5 := range v.Tags {
+			v.Tags[i5] = d.string()
+		}
+	} else if v.Tags != nil {
+		v.Tags = nil
+	}
+	if d.bool() {
+		l6 := d.length()
+		if v.Attrs == nil {
+			v.Attrs = make(map[string]int, l6)
+		}
+		for i7 := 0; i7 < l6; i7++ {
+			var k8 string
+			var v9 int
+			k8 = d.string()
+			v9 = int(d.int64())
+			v.Attrs[k8] = v9
+		}
+	} else if v.Attrs != nil {
+		v.Attrs = nil
+	}
+	if d.bool() {
+		l10 := d.length()
+		if v.Set == nil {
+			v.Set = make(map[int16]struct{}, l10)
+		}
+		for i11 := 0; i11 < l10; i11++ {
+			var k12 int16
+			var v13 struct{}
+			k12 = d.int16()
+			v.Set[k12] = v13
+		}
+	} else if v.Set != nil {
+		v.Set = nil
+	}
+	if d.bool() {
+		if v.Parent == nil {
+			v.Parent = new(Record)
+		}
+		d.index += v.Parent.GotinyDecodeBools(d.buf[d.index:], &d.boolPos, &d.boolBit)
+	} else if v.Parent != nil {
+		v.Parent = nil
+	}
+	if d.bool() {
+		l14 := d.length()
+		if v.Children == nil || cap(v.Children) < l14 {
+			v.Children = make([]*Record, l14)
+		} else {
+			v.Children = v.Children[:l14]
+		}
+		for i15 := range v.Children {
+			if d.bool() {
+				if v.Children[i15] == nil {
+					v.Children[i15] = new(Record)
+				}
+				d.index += v.Children[i15].GotinyDecodeBools(d.buf[d.index:], &d.boolPos, &d.boolBit)
+			} else if v.Children[i15] != nil {
+				v.Children[i15] = nil
+			}
+		}
+	} else if v.Children != nil {
+		v.Children = nil
+	}
+	gotinyDecodeStatus(d, &v.Status)
+	gotinyDecodePoint(d, &v.Point)
+	if d.bool() {
+		if v.Version == nil {
+			v.Version = new(Version)
+		}
+		{
+			l := d.length()
+			start := d.index
+			d.index += l
+			if err := v.Version.UnmarshalBinary(d.buf[start:d.index]); err != nil {
+				panic(err)
+			}
+		}
+	} else if v.Version != nil {
+		v.Version = nil
+	}
+	d.index += v.Matrix.GotinyDecodeBools(d.buf[d.index:], &d.boolPos, &d.boolBit)
+	gotinyDecodeinner(d, &v.inner)
+	*bools, *boolBit = d.boolPos, d.boolBit
+	return d.index
+}
+
+// GotinyEncode appends encoded value to buf, it implements gotiny.GoTinySerializer.
+func (v *Matrix) GotinyEncode(buf []byte) []byte {
+	var boolPos int
+	var boolBit byte
+	return v.GotinyEncodeBools(buf, &boolPos, &boolBit)
+}
+
+// GotinyEncodeBools appends encoded value to buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.
+func (v *Matrix) GotinyEncodeBools(buf []byte, boolPos *int, boolBit *byte) []byte {
+	e := &gotinyEncoder{buf: buf, boolPos: *boolPos, boolBit: *boolBit}
+	for i1 := range *v {
+		for i2 := range (*v)[i1] {
+			e.uint64(bits.ReverseBytes64(math.Float64bits((*v)[i1][i2])))
+		}
+	}
+	*boolPos, *boolBit = e.boolPos, e.boolBit
+	return e.buf
+}
+
+// GotinyDecode decodes value from buf and returns number of bytes read, it implements gotiny.GoTinySerializer.
+func (v *Matrix) GotinyDecode(buf []byte) int {
+	var bools, boolBit byte
+	return v.GotinyDecodeBools(buf, &bools, &boolBit)
+}
+
+// GotinyDecodeBools decodes value from buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.
+func (v *Matrix) GotinyDecodeBools(buf []byte, bools, boolBit *byte) int {
+	d := &gotinyDecoder{buf: buf, boolPos: *bools, boolBit: *boolBit}
+	for i1 := range *v {
+		for i2 := range (*v)[i1] {
+			(*v)[i1][i2] = math.Float64frombits(bits.ReverseBytes64(d.uint64()))
+		}
+	}
+	*bools, *boolBit = d.boolPos, d.boolBit
+	return d.index
+}
+
+// GotinyEncode appends encoded value to buf, it implements gotiny.GoTinySerializer.
+func (v *Node) GotinyEncode(buf []byte) []byte {
+	var boolPos int
+	var boolBit byte
+	return v.GotinyEncodeBools(buf, &boolPos, &boolBit)
+}
+
+// GotinyEncodeBools appends encoded value to buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.
+func (v *Node) GotinyEncodeBools(buf []byte, boolPos *int, boolBit *byte) []byte {
+	e := &gotinyEncoder{buf: buf, boolPos: *boolPos, boolBit: *boolBit}
+	e.bool(v.Flag)
+	e.bool(v.Next != nil)
+	if v.Next != nil {
+		e.buf = v.Next.GotinyEncodeBools(e.buf, &e.boolPos, &e.boolBit)
+	}
+	*boolPos, *boolBit = e.boolPos, e.boolBit
+	return e.buf
+}
+
+// GotinyDecode decodes value from buf and returns number of bytes read, it implements gotiny.GoTinySerializer.
+func (v *Node) GotinyDecode(buf []byte) int {
+	var bools, boolBit byte
+	return v.GotinyDecodeBools(buf, &bools, &boolBit)
+}
+
+// GotinyDecodeBools decodes value from buf sharing bytes of bools with enclosing value, it implements gotiny.GoTinyBoolSerializer.
+func (v *Node) GotinyDecodeBools(buf []byte, bools, boolBit *byte) int {
+	d := &gotinyDecoder{buf: buf, boolPos: *bools, boolBit: *boolBit}
+	v.Flag = d.bool()
+	if d.bool() {
+		if v.Next == nil {
+			v.Next = new(Node)
+		}
+		d.index += v.Next.GotinyDecodeBools(d.buf[d.index:], &d.boolPos, &d.boolBit)
+	} else if v.Next != nil {
+		v.Next = nil
+	}
+	*bools, *boolBit = d.boolPos, d.boolBit
+	return d.index
+}
+
+func gotinyEncodeBlob(e *gotinyEncoder, v *Blob) {
+	e.bytes([]byte(*v))
+}
+
+func gotinyDecodeBlob(d *gotinyDecoder, v *Blob) {
+	if d.bool() {
+		l1 := d.length()
+		if *v == nil || cap(*v) < l1 {
+			*v = make(Blob, l1)
+		} else {
+			*v = (*v)[:l1]
+		}
+		copy(*v, d.buf[d.index:d.index+l1])
+		d.index += l1
+	} else if *v != nil {
+		*v = nil
+	}
+}
+
+func gotinyEncodeStatus(e *gotinyEncoder, v *Status) {
+	e.int16(int16(*v))
+}
+
+func gotinyDecodeStatus(d *gotinyDecoder, v *Status) {
+	*v = Status(d.int16())
+}
+
+func gotinyEncodePoint(e *gotinyEncoder, v *Point) {
+	e.int64(int64(v.X))
+	e.int64(int64(v.Y))
+}
+
+func gotinyDecodePoint(d *gotinyDecoder, v *Point) {
+	v.X = int(d.int64())
+	v.Y = int(d.int64())
+}
+
+func gotinyEncodeinner(e *gotinyEncoder, v *inner) {
+	e.uint64(uint64(v.a))
+	e.bool(v.b != nil)
+	if v.b != nil {
+		e.int64(int64(*v.b))
+	}
+	e.bool(v.c != nil)
+	if v.c != nil {
+		e.length(len(v.c))
+		for k1, v2 := range v.c {
+			e.uint64(uint64(k1))
+			e.bool(v2 != nil)
+			if v2 != nil {
+				e.length(len(v2))
+				for i3 := range v2 {
+					gotinyEncodePoint(e, &v2[i3])
+				}
+			}
+		}
+	}
+}
+
+func gotinyDecodeinner(d *gotinyDecoder, v *inner) {
+	v.a = uint(d.uint64())
+	if d.bool() {
+		if v.b == nil {
+			v.b = new(int)
+		}
+		*v.b = int(d.int64())
+	} else if v.b != nil {
+		v.b = nil
+	}
+	if d.bool() {
+		l1 := d.length()
+		if v.c == nil {
+			v.c = make(map[uintptr][]Point, l1)
+		}
+		for i2 := 0; i2 < l1; i2++ {
+			var k3 uintptr
+			var v4 []Point
+			k3 = uintptr(d.uint64())
+			if d.bool() {
+				l5 := d.length()
+				if v4 == nil || cap(v4) < l5 {
+					v4 = make([]Point, l5)
+				} else {
+					v4 = v4[:l5]
+				}
+				for i6 := range v4 {
+					gotinyDecodePoint(d, &v4[i6])
+				}
+			} else if v4 != nil {
+				v4 = nil
+			}
+			v.c[k3] = v4
+		}
+	} else if v.c != nil {
+		v.c = nil
+	}
+}
+
+type gotinyEncoder struct {
+	buf     []byte
+	boolPos int
+	boolBit byte
+}
+
+func (e *gotinyEncoder) bool(v bool) {
+	if e.boolBit == 0 {
+		e.boolPos = len(e.buf)
+		e.buf = append(e.buf, 0)
+		e.boolBit = 1
+	}
+	if v {
+		e.buf[e.boolPos] |= e.boolBit
+	}
+	e.boolBit <<= 1
+}
+
+func (e *gotinyEncoder) uint64(v uint64) {
+	switch {
+	case v < 1<<7-1:
+		e.buf = append(e.buf, byte(v))
+	case v < 1<<14-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7))
+	case v < 1<<21-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14))
+	case v < 1<<28-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21))
+	case v < 1<<35-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28))
+	case v < 1<<42-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35))
+	case v < 1<<49-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35)|0x80, byte(v>>42))
+	case v < 1<<56-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35)|0x80, byte(v>>42)|0x80, byte(v>>49))
+	default:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28)|0x80, byte(v>>35)|0x80, byte(v>>42)|0x80, byte(v>>49)|0x80, byte(v>>56))
+	}
+}
+
+func (e *gotinyEncoder) uint32(v uint32) {
+	switch {
+	case v < 1<<7-1:
+		e.buf = append(e.buf, byte(v))
+	case v < 1<<14-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7))
+	case v < 1<<21-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14))
+	case v < 1<<28-1:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21))
+	default:
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14)|0x80, byte(v>>21)|0x80, byte(v>>28))
+	}
+}
+
+func (e *gotinyEncoder) uint16(v uint16) {
+	if v < 1<<7-1 {
+		e.buf = append(e.buf, byte(v))
+	} else if v < 1<<14-1 {
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7))
+	} else {
+		e.buf = append(e.buf, byte(v)|0x80, byte(v>>7)|0x80, byte(v>>14))
+	}
+}
+
+func (e *gotinyEncoder) int64(v int64)   { e.uint64(uint64((v << 1) ^ (v >> 63))) }
+func (e *gotinyEncoder) int32(v int32)   { e.uint32(uint32((v << 1) ^ (v >> 31))) }
+func (e *gotinyEncoder) int16(v int16)   { e.uint16(uint16((v << 1) ^ (v >> 15))) }
+func (e *gotinyEncoder) length(l int)    { e.uint32(uint32(l)) }
+func (e *gotinyEncoder) string(s string) { e.uint32(uint32(len(s))); e.buf = append(e.buf, s...) }
+
+func (e *gotinyEncoder) bytes(b []byte) {
+	e.bool(b != nil)
+	if b != nil {
+		e.uint32(uint32(len(b)))
+		e.buf = append(e.buf, b...)
+	}
+}
+
+type gotinyDecoder struct {
+	buf     []byte
+	index   int
+	boolPos byte
+	boolBit byte
+}
+
+func (d *gotinyDecoder) bool() (b bool) {
+	if d.boolBit == 0 {
+		d.boolBit = 1
+		d.boolPos = d.buf[d.index]
+		d.index++
+	}
+	b = d.boolPos&d.boolBit != 0
+	d.boolBit <<= 1
+	return
+}
+
+func (d *gotinyDecoder) uint64() uint64 {
+	buf, i := d.buf, d.index
+	x := uint64(buf[i])
+	if x < 0x80 {
+		d.index++
+		return x
+	}
+	x1 := buf[i+1]
+	x += uint64(x1) << 7
+	if x1 < 0x80 {
+		d.index += 2
+		return x - 1<<7
+	}
+	x2 := buf[i+2]
+	x += uint64(x2) << 14
+	if x2 < 0x80 {
+		d.index += 3
+		return x - (1<<7 + 1<<14)
+	}
+	x3 := buf[i+3]
+	x += uint64(x3) << 21
+	if x3 < 0x80 {
+		d.index += 4
+		return x - (1<<7 + 1<<14 + 1<<21)
+	}
+	x4 := buf[i+4]
+	x += uint64(x4) << 28
+	if x4 < 0x80 {
+		d.index += 5
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28)
+	}
+	x5 := buf[i+5]
+	x += uint64(x5) << 35
+	if x5 < 0x80 {
+		d.index += 6
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35)
+	}
+	x6 := buf[i+6]
+	x += uint64(x6) << 42
+	if x6 < 0x80 {
+		d.index += 7
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35 + 1<<42)
+	}
+	x7 := buf[i+7]
+	x += uint64(x7) << 49
+	if x7 < 0x80 {
+		d.index += 8
+		return x - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35 + 1<<42 + 1<<49)
+	}
+	d.index += 9
+	return x + uint64(buf[i+8])<<56 - (1<<7 + 1<<14 + 1<<21 + 1<<28 + 1<<35 + 1<<42 + 1<<49 + 1<<56)
+}
+
+func (d *gotinyDecoder) uint32() uint32 {
+	buf, i := d.buf, d.index
+	x := uint32(buf[i])
+	if x < 0x80 {
+		d.index++
+		return x
+	}
+	x1 := buf[i+1]
+	x += uint32(x1) << 7
+	if x1 < 0x80 {
+		d.index += 2
+		return x - 1<<7
+	}
+	x2 := buf[i+2]
+	x += uint32(x2) << 14
+	if x2 < 0x80 {
+		d.index += 3
+		return x - (1<<7 + 1<<14)
+	}
+	x3 := buf[i+3]
+	x += uint32(x3) << 21
+	if x3 < 0x80 {
+		d.index += 4
+		return x - (1<<7 + 1<<14 + 1<<21)
+	}
+	x4 := buf[i+4]
+	x += uint32(x4) << 28
+	d.index += 5
+	return x - (1<<7 + 1<<14 + 1<<21 + 1<<28)
+}
+
+func (d *gotinyDecoder) uint16() uint16 {
+	buf, i := d.buf, d.index
+	x := uint16(buf[i])
+	if x < 0x80 {
+		d.index++
+		return x
+	}
+	x1 := buf[i+1]
+	x += uint16(x1) << 7
+	if x1 < 0x80 {
+		d.index += 2
+		return x - 1<<7
+	}
+	d.index += 3
+	return x + uint16(buf[i+2])<<14 - (1<<7 + 1<<14)
+}
+
+func (d *gotinyDecoder) int64() int64 {
+	v := int64(d.uint64())
+	return (-(v & 1)) ^ (v>>1)&0x7FFFFFFFFFFFFFFF
+}
+
+func (d *gotinyDecoder) int32() int32 {
+	v := int32(d.uint32())
+	return (-(v & 1)) ^ (v>>1)&0x7FFFFFFF
+}
+
+func (d *gotinyDecoder) int16() int16 {
+	v := int16(d.uint16())
+	return (-(v & 1)) ^ (v>>1)&0x7FFF
+}
+
+func (d *gotinyDecoder) byte() byte {
+	b := d.buf[d.index]
+	d.index++
+	return b
+}
+
+func (d *gotinyDecoder) length() int { return int(d.uint32()) }
+
+func (d *gotinyDecoder) string() string {
+	l := int(d.uint32())
+	s := string(d.buf[d.index : d.index+l])
+	d.index += l
+	return s
+}
+
+func (d *gotinyDecoder) bytes() []byte {
+	if !d.bool() {
+		return nil
+	}
+	l := int(d.uint32())
+	b := d.buf[d.index : d.index+l]
+	d.index += l
+	return b
+}
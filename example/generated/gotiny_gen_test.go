@@ -0,0 +1,243 @@
+// Code generated by gotinygen. DO NOT EDIT.
+
+package generated
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+func TestGotinyGenerated(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		{
+			v := new(Record)
+			if i > 0 {
+				gotinyRandom(r, reflect.ValueOf(v).Elem(), 0)
+			}
+			gotinyCheck(t, "Record", v, (*gotinyPlainRecord)(unsafe.Pointer(v)), new(Record), new(gotinyPlainRecord))
+			w := &struct {
+				A bool
+				V Record
+			}{A: i%2 == 0, V: *v}
+			gotinyCheckWrapped(t, "wrapped Record", w, (*struct {
+				A bool
+				V gotinyPlainRecord
+			})(unsafe.Pointer(w)), new(struct {
+				A bool
+				V Record
+			}), new(struct {
+				A bool
+				V gotinyPlainRecord
+			}))
+		}
+		{
+			v := new(Matrix)
+			if i > 0 {
+				gotinyRandom(r, reflect.ValueOf(v).Elem(), 0)
+			}
+			gotinyCheck(t, "Matrix", v, (*gotinyPlainMatrix)(unsafe.Pointer(v)), new(Matrix), new(gotinyPlainMatrix))
+			w := &struct {
+				A bool
+				V Matrix
+			}{A: i%2 == 0, V: *v}
+			gotinyCheckWrapped(t, "wrapped Matrix", w, (*struct {
+				A bool
+				V gotinyPlainMatrix
+			})(unsafe.Pointer(w)), new(struct {
+				A bool
+				V Matrix
+			}), new(struct {
+				A bool
+				V gotinyPlainMatrix
+			}))
+		}
+		{
+			v := new(Node)
+			if i > 0 {
+				gotinyRandom(r, reflect.ValueOf(v).Elem(), 0)
+			}
+			gotinyCheck(t, "Node", v, (*gotinyPlainNode)(unsafe.Pointer(v)), new(Node), new(gotinyPlainNode))
+			w := &struct {
+				A bool
+				V Node
+			}{A: i%2 == 0, V: *v}
+			gotinyCheckWrapped(t, "wrapped Node", w, (*struct {
+				A bool
+				V gotinyPlainNode
+			})(unsafe.Pointer(w)), new(struct {
+				A bool
+				V Node
+			}), new(struct {
+				A bool
+				V gotinyPlainNode
+			}))
+		}
+	}
+}
+
+type gotinyPlainRecord struct {
+	ID      uint64
+	Name    string
+	Active  bool
+	Flags   [3]bool
+	Score   float64
+	Ratio   float32
+	Delta   int32
+	Small   int8
+	Count   uint16
+	Phase   complex64
+	Wave    complex128
+	Created time.Time
+	Data    []byte
+	Raw     gotinyPlainBlob
+	Tags    []string
+	Attrs   map[string]int
+	Set     map[int16]struct {
+	}
+	Parent   *gotinyPlainRecord
+	Children []*gotinyPlainRecord
+	Status   gotinyPlainStatus
+	Point    gotinyPlainPoint
+	Version  *Version
+	Matrix   gotinyPlainMatrix
+	inner    gotinyPlaininner
+	Cache    string "gotiny:\"-\""
+}
+
+type gotinyPlainMatrix [2][2]float64
+
+type gotinyPlainNode struct {
+	Flag bool
+	Next *gotinyPlainNode
+}
+
+type gotinyPlainBlob []byte
+
+type gotinyPlainPoint struct {
+	X int
+	Y int
+}
+
+type gotinyPlainStatus int16
+
+type gotinyPlaininner struct {
+	a uint
+	b *int
+	c map[uintptr][]gotinyPlainPoint
+}
+
+func gotinyCheck(t *testing.T, name string, v gotiny.GoTinySerializer, plain interface{}, ret gotiny.GoTinySerializer, plainRet interface{}) {
+	t.Helper()
+	got, exp := v.GotinyEncode(nil), gotiny.Marshal(plain)
+	if !bytes.Equal(got, exp) {
+		t.Fatalf("%s: generated encoder wrote\n%v\nreflective encoder wrote\n%v", name, got, exp)
+	}
+	if via := gotiny.Marshal(v); !bytes.Equal(via, got) {
+		t.Fatalf("%s: gotiny wrote with generated methods\n%v\nexpected\n%v", name, via, got)
+	}
+	if n := ret.GotinyDecode(got); n != len(got) {
+		t.Fatalf("%s: generated decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := ret.GotinyEncode(nil); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by generated decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+	if n := gotiny.Unmarshal(got, plainRet); n != len(got) {
+		t.Fatalf("%s: reflective decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := gotiny.Marshal(plainRet); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by reflective decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+}
+
+// gotinyCheckWrapped checks value which is encoded by gotiny with generated methods of its field
+func gotinyCheckWrapped(t *testing.T, name string, v, plain, ret, plainRet interface{}) {
+	t.Helper()
+	got, exp := gotiny.Marshal(v), gotiny.Marshal(plain)
+	if !bytes.Equal(got, exp) {
+		t.Fatalf("%s: generated encoder wrote\n%v\nreflective encoder wrote\n%v", name, got, exp)
+	}
+	if n := gotiny.Unmarshal(got, ret); n != len(got) {
+		t.Fatalf("%s: generated decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := gotiny.Marshal(ret); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by generated decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+	if n := gotiny.Unmarshal(got, plainRet); n != len(got) {
+		t.Fatalf("%s: reflective decoder read %d bytes of %d", name, n, len(got))
+	}
+	if again := gotiny.Marshal(plainRet); !bytes.Equal(again, got) {
+		t.Fatalf("%s: value decoded by reflective decoder is encoded as\n%v\nexpected\n%v", name, again, got)
+	}
+}
+
+// gotinyRandom fills v with random value, maps get at most one element to have determined order
+func gotinyRandom(r *rand.Rand, v reflect.Value, depth int) {
+	if !v.CanSet() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(time.Unix(0, r.Int63())))
+		return
+	}
+	deep := depth > 3
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63() >> uint(r.Intn(63)) * int64(1-2*r.Intn(2)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(r.Uint64() >> uint(r.Intn(64)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.NormFloat64() * 1e6)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(complex(r.NormFloat64(), r.NormFloat64()))
+	case reflect.String:
+		b := make([]byte, r.Intn(16))
+		r.Read(b)
+		v.SetString(string(b))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			gotinyRandom(r, v.Index(i), depth+1)
+		}
+	case reflect.Slice:
+		if r.Intn(4) == 0 {
+			return
+		}
+		n := r.Intn(4)
+		if deep {
+			n = 0
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			gotinyRandom(r, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if r.Intn(4) == 0 {
+			return
+		}
+		v.Set(reflect.MakeMap(v.Type()))
+		if !deep && r.Intn(2) == 0 {
+			key, val := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			gotinyRandom(r, key, depth+1)
+			gotinyRandom(r, val, depth+1)
+			v.SetMapIndex(key, val)
+		}
+	case reflect.Ptr:
+		if deep || r.Intn(3) == 0 {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		gotinyRandom(r, v.Elem(), depth+1)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			gotinyRandom(r, v.Field(i), depth+1)
+		}
+	}
+}
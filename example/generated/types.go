@@ -0,0 +1,89 @@
+// Package generated shows types with serializer methods generated by gotinygen,
+// gotiny_gen_test.go checks generated methods write the same bytes as reflective engines.
+package generated
+
+import (
+	"errors"
+	"time"
+)
+
+//go:generate go run github.com/niubaoshu/gotiny/cmd/gotinygen -test
+
+// Record has fields of every kind gotinygen supports
+//
+//gotiny:generate
+type Record struct {
+	ID       uint64
+	Name     string
+	Active   bool
+	Flags    [3]bool
+	Score    float64
+	Ratio    float32
+	Delta    int32
+	Small    int8
+	Count    uint16
+	Phase    complex64
+	Wave     complex128
+	Created  time.Time
+	Data     []byte
+	Raw      Blob
+	Tags     []string
+	Attrs    map[string]int
+	Set      map[int16]struct{}
+	Parent   *Record
+	Children []*Record
+	Status   Status
+	Point    Point
+	Version  *Version
+	Matrix   Matrix
+	inner    inner
+	Cache    string `gotiny:"-"`
+}
+
+type (
+	// Status is encoded as int16 it is based on
+	Status int16
+
+	// Blob is byte slice with its own name, it is copied on decoding unlike []byte
+	Blob []byte
+
+	Point struct {
+		X, Y int
+	}
+
+	inner struct {
+		a uint
+		b *int
+		c map[uintptr][]Point
+	}
+)
+
+// Matrix is encoded by methods of its own
+//
+//gotiny:generate
+type Matrix [2][2]float64
+
+// Version has its own binary form, gotiny and generated code both use it
+type Version struct {
+	Major, Minor uint8
+}
+
+func (v *Version) MarshalBinary() ([]byte, error) {
+	return []byte{v.Major, v.Minor}, nil
+}
+
+func (v *Version) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("generated: invalid version")
+	}
+	v.Major, v.Minor = data[0], data[1]
+	return nil
+}
+
+// Node shares bytes of bools with nodes it points to, as reflective engines do
+//
+//gotiny:generate
+type Node struct {
+	Flag bool
+	Next *Node
+}
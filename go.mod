@@ -1,5 +1,5 @@
 module github.com/niubaoshu/gotiny
 
-go 1.12
+go 1.20
 
 require github.com/niubaoshu/goutils v0.0.0-20180828035119-e8e576f66c2b
@@ -199,3 +199,147 @@ func BenchmarkDecodeBool(b *testing.B) {
 		}
 	}
 }
+
+var benchMap = func() map[uint32]float64 {
+	m := make(map[uint32]float64, 100)
+	for i := uint32(0); i < 100; i++ {
+		m[i*7] = float64(i) / 3
+	}
+	return m
+}()
+
+func BenchmarkEncodeMap(b *testing.B) {
+	e := NewEncoderWithPtr(&benchMap)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e.Encode(&benchMap)
+	}
+}
+
+func BenchmarkDecodeMap(b *testing.B) {
+	data := Marshal(&benchMap)
+	d := NewDecoderWithPtr(&benchMap)
+	m := new(map[uint32]float64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		*m = nil
+		d.Decode(data, m)
+	}
+}
+
+func BenchmarkDecodeMapReuse(b *testing.B) {
+	data := Marshal(&benchMap)
+	d := NewDecoderWithPtr(&benchMap)
+	m := map[uint32]float64{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.Decode(data, &m)
+	}
+}
+
+type benchFloats struct {
+	Packed   []float64 `gotiny:"packed"`
+	Unpacked []float64
+}
+
+var benchPacked = func() benchFloats {
+	f := make([]float64, 1000)
+	for i := range f {
+		f[i] = float64(i) / 3
+	}
+	return benchFloats{Packed: f, Unpacked: f}
+}()
+
+func BenchmarkEncodePacked(b *testing.B) {
+	e := NewEncoderWithPtr(&benchPacked)
+	v := benchFloats{Packed: benchPacked.Packed}
+	b.SetBytes(int64(len(v.Packed) * 8))
+	for i := 0; i < b.N; i++ {
+		e.Encode(&v)
+	}
+}
+
+func BenchmarkEncodeUnpacked(b *testing.B) {
+	e := NewEncoderWithPtr(&benchPacked)
+	v := benchFloats{Unpacked: benchPacked.Unpacked}
+	b.SetBytes(int64(len(v.Unpacked) * 8))
+	for i := 0; i < b.N; i++ {
+		e.Encode(&v)
+	}
+}
+
+func BenchmarkDecodePacked(b *testing.B) {
+	data := Marshal(&benchFloats{Packed: benchPacked.Packed})
+	d := NewDecoderWithPtr(&benchPacked)
+	var v benchFloats
+	b.SetBytes(int64(len(benchPacked.Packed) * 8))
+	for i := 0; i < b.N; i++ {
+		d.Decode(data, &v)
+	}
+}
+
+func BenchmarkDecodeUnpacked(b *testing.B) {
+	data := Marshal(&benchFloats{Unpacked: benchPacked.Unpacked})
+	d := NewDecoderWithPtr(&benchPacked)
+	var v benchFloats
+	b.SetBytes(int64(len(benchPacked.Unpacked) * 8))
+	for i := 0; i < b.N; i++ {
+		d.Decode(data, &v)
+	}
+}
+
+func BenchmarkSize(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Size(value)
+	}
+}
+
+var benchStrings = func() []string {
+	s := make([]string, 100)
+	for i := range s {
+		s[i] = "string of record number " + string(rune('a'+i%26))
+	}
+	return s
+}()
+
+func benchmarkDecodeStrings(b *testing.B, zeroCopy bool) {
+	data := Marshal(&benchStrings)
+	d := NewDecoderWithPtr(&benchStrings)
+	d.SetZeroCopyStrings(zeroCopy)
+	s := make([]string, len(benchStrings))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.Decode(data, &s)
+	}
+}
+
+func BenchmarkDecodeStrings(b *testing.B)         { benchmarkDecodeStrings(b, false) }
+func BenchmarkDecodeStringsZeroCopy(b *testing.B) { benchmarkDecodeStrings(b, true) }
+
+var benchChunked = func() []A {
+	s := make([]A, 1000)
+	for i := range s {
+		s[i] = *genA()
+	}
+	return s
+}()
+
+func benchmarkChunked(b *testing.B, ch Chunking) {
+	coder := NewWithPtr(&benchChunked)
+	data := coder.EncodeChunked(&benchChunked, ch)
+	s := make([]A, len(benchChunked))
+	b.Run("Encode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			coder.EncodeChunked(&benchChunked, ch)
+		}
+	})
+	b.Run("Decode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			coder.DecodeChunked(data, &s, ch)
+		}
+	})
+}
+
+func BenchmarkSequential(b *testing.B) { benchmarkChunked(b, Chunking{}) }
+func BenchmarkChunked(b *testing.B)    { benchmarkChunked(b, Chunking{ChunkLen: 100}) }
@@ -149,6 +149,7 @@ var (
 	v5map       = map[int]baseTyp{1: genBase(), 2: genBase()}
 	v6map       = map[*int]baseTyp{&vint1: genBase(), &vint2: genBase()}
 	v7map       = map[int][3]baseTyp{1: varr}
+	v8map       = map[string][]int{"a": {1, 2}, "b": {3}, "c": nil}
 	vnilmap     map[int]int
 	vptr        = &vint
 	vsliceptr   = &vbytes
@@ -256,6 +257,7 @@ var (
 		v5map,
 		v6map,
 		v7map,
+		v8map,
 		vnilmap,
 		vptr,
 		vsliceptr,
@@ -327,8 +329,8 @@ func init() {
 		retv[i] = tempv.Elem()
 		reti[i] = tempv.Interface()
 
-		srcp[i] = unsafe.Pointer(reflect.ValueOf(&srci[i]).Elem().InterfaceData()[1])
-		retp[i] = unsafe.Pointer(reflect.ValueOf(&reti[i]).Elem().InterfaceData()[1])
+		srcp[i] = tempi.UnsafePointer()
+		retp[i] = tempv.UnsafePointer()
 	}
 	fmt.Printf("total %d value. buf length: %d, encode length: %d \n", length, cap(buf), len(gotiny.Marshal(srci...)))
 }
@@ -0,0 +1,290 @@
+// Package idl parses .tiny files describing gotiny schemes without go types,
+// so contract of data is a reviewable file which drives DecodeDynamic, EncodeDynamic,
+// transcoders and go code generation by Scheme.GoSource.
+//
+// File starts with package path, which prefixes names of declared types the same way
+// as gotiny names go types, and declares structs and unions:
+//
+//	package "github.com/acme/model"
+//
+//	// Shape is one of figures
+//	union Shape {
+//		Circle
+//		Square
+//	}
+//
+//	struct Circle {
+//		1: Radius float64
+//	}
+//
+//	struct Square {
+//		1: Side float64
+//	}
+//
+//	struct Record {
+//		1: ID     uint64
+//		2: Name   string
+//		3: Tags   list<string>
+//		4: Attrs  map<string, int32>
+//		5: Parent optional<Record>
+//		6: Shape  Shape
+//		7: Data   bytes
+//		8: At     time
+//		9: Grid   array<3, float32>
+//	}
+//
+// Fields are encoded in order of their ids, so fields may be reordered in file without changing data.
+// Primitive types are bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+// float32, float64, complex64, complex128, string, bytes and time, int and uint are 64 bits wide.
+// list, map and optional are go slices, maps and pointers, nil is encoded as nil.
+// Union is interface which value is one of member structs, value is encoded with name of its type.
+// Schemes of union members are children of interface node, so dynamic coding doesn't need go types.
+package idl
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+// primitives are go types of primitive types, names of types are the same as names of scheme types
+var primitives = map[string]string{
+	"bool": "bool", "int": "int", "int8": "int8", "int16": "int16", "int32": "int32", "int64": "int64",
+	"uint": "uint", "uint8": "uint8", "uint16": "uint16", "uint32": "uint32", "uint64": "uint64",
+	"float32": "float32", "float64": "float64", "complex64": "complex64", "complex128": "complex128",
+	"string": "string", "bytes": "[]uint8", "time": "time.Time",
+}
+
+// File is parsed .tiny file
+type File struct {
+	Package string
+	Names   []string // names of declared types in order of declaration
+	types   map[string]*gotiny.Scheme
+	decls   map[string]*decl
+}
+
+// ParseFile reads and parses .tiny file
+func ParseFile(filename string) (*File, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(src)
+	if err != nil {
+		return nil, errors.New(filename + ":" + err.Error())
+	}
+	return f, nil
+}
+
+// Parse parses source of .tiny file, errors are prefixed with line and column
+func Parse(src []byte) (f *File, err error) {
+	tokens, err := lex(string(src))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+			f, err = nil, e
+		}
+	}()
+	p := &parser{tokens: tokens}
+	pkg, decls := p.file()
+	f = &File{Package: pkg, types: map[string]*gotiny.Scheme{}, decls: map[string]*decl{}}
+	for _, d := range decls {
+		if _, ok := f.decls[d.name]; ok {
+			panic(fmt.Errorf("%s: %s is declared twice", d.pos, d.name))
+		}
+		if _, ok := primitives[d.name]; ok || isContainer(d.name) {
+			panic(fmt.Errorf("%s: %s is name of builtin type", d.pos, d.name))
+		}
+		f.decls[d.name] = d
+		f.Names = append(f.Names, d.name)
+	}
+	f.build(decls)
+	return f, nil
+}
+
+// Type returns scheme of declared type, nil if it isn't declared
+func (f *File) Type(name string) *gotiny.Scheme {
+	return f.types[name]
+}
+
+// Scheme returns scheme of coder of values of declared types, of all declared structs if names are omitted.
+// Data encoded by coder of go types with the same names and fields is decoded with it.
+func (f *File) Scheme(names ...string) (*gotiny.Scheme, error) {
+	if len(names) == 0 {
+		for _, name := range f.Names {
+			if f.decls[name].kind == "struct" {
+				names = append(names, name)
+			}
+		}
+	}
+	root := &gotiny.Scheme{}
+	for _, name := range names {
+		node, ok := f.types[name]
+		if !ok {
+			return nil, errors.New("idl: type " + name + " is not declared")
+		}
+		value := *node
+		value.Name = ""
+		root.Childs = append(root.Childs, &value)
+	}
+	if len(root.Childs) == 0 {
+		return nil, errors.New("idl: no types to encode")
+	}
+	return root, nil
+}
+
+// build creates scheme nodes of declarations, nodes of fields of declared types are their copies with names,
+// which share children with declaration, so declarations are created before children are filled.
+func (f *File) build(decls []*decl) {
+	for _, d := range decls {
+		if d.kind == "struct" {
+			f.checkSize(d, map[string]bool{})
+			f.types[d.name] = node("struct", "", f.goType(d.name))
+			f.types[d.name].Childs = make([]*gotiny.Scheme, len(d.fields))
+		} else {
+			f.types[d.name] = node("interface", "", f.goType(d.name))
+			f.types[d.name].Childs = make([]*gotiny.Scheme, len(d.members))
+			// impls aren't shared by copies, they are filled before fields refer to union
+			for _, member := range d.members {
+				f.types[d.name].Impls = append(f.types[d.name].Impls, f.goType(member.name))
+			}
+		}
+	}
+	for _, d := range decls {
+		s := f.types[d.name]
+		if d.kind == "union" {
+			for i, member := range d.members {
+				if md := f.decls[member.name]; md == nil || md.kind != "struct" {
+					panic(fmt.Errorf("%s: member %s of union %s is not declared struct", member.pos, member.name, d.name))
+				}
+				s.Childs[i] = f.ref(member, f.goType(member.name))
+			}
+			continue
+		}
+		sort.SliceStable(d.fields, func(i, j int) bool { return d.fields[i].id < d.fields[j].id })
+		names := map[string]bool{}
+		for i, fd := range d.fields {
+			if i > 0 && d.fields[i-1].id == fd.id {
+				panic(fmt.Errorf("%s: id %d is used by fields %s and %s", fd.pos, fd.id, d.fields[i-1].name, fd.name))
+			}
+			if names[fd.name] {
+				panic(fmt.Errorf("%s: field %s is declared twice", fd.pos, fd.name))
+			}
+			names[fd.name] = true
+			s.Childs[i] = f.node(fd.typ, fd.name)
+		}
+	}
+}
+
+// node creates scheme node of type
+func (f *File) node(t *typeExpr, name string) *gotiny.Scheme {
+	switch t.kind {
+	case "ref":
+		return f.ref(t, name)
+	case "list":
+		return node("slice", name, f.typeName(t), f.node(t.elems[0], ""))
+	case "optional":
+		return node("pointer", name, f.typeName(t), f.node(t.elems[0], ""))
+	case "array":
+		s := node("array", name, f.typeName(t), f.node(t.elems[0], ""))
+		s.Len = t.len
+		return s
+	case "map":
+		if !f.comparable(t.elems[0]) {
+			panic(fmt.Errorf("%s: key of map can't be list, map, bytes or struct containing them", t.elems[0].pos))
+		}
+		return node("map", name, f.typeName(t), f.node(t.elems[0], "key"), f.node(t.elems[1], "value"))
+	}
+	return node(t.kind, name, primitives[t.kind])
+}
+
+// ref returns copy of node of declared type
+func (f *File) ref(t *typeExpr, name string) *gotiny.Scheme {
+	s, ok := f.types[t.name]
+	if !ok {
+		panic(fmt.Errorf("%s: type %s is not declared", t.pos, t.name))
+	}
+	c := *s
+	c.Name = name
+	return &c
+}
+
+func node(typ, name, goType string, childs ...*gotiny.Scheme) *gotiny.Scheme {
+	s := &gotiny.Scheme{Name: name, GoType: goType, Childs: childs}
+	if err := s.Type.UnmarshalText([]byte(typ)); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (f *File) goType(name string) string {
+	return f.Package + "." + name
+}
+
+// typeName returns name of go type as gotiny names it
+func (f *File) typeName(t *typeExpr) string {
+	switch t.kind {
+	case "ref":
+		return f.goType(t.name)
+	case "list":
+		return "[]" + f.typeName(t.elems[0])
+	case "optional":
+		return "*" + f.typeName(t.elems[0])
+	case "array":
+		return "[" + strconv.Itoa(t.len) + "]" + f.typeName(t.elems[0])
+	case "map":
+		return "map[" + f.typeName(t.elems[0]) + "]" + f.typeName(t.elems[1])
+	}
+	return primitives[t.kind]
+}
+
+// comparable reports whether type may be key of go map
+func (f *File) comparable(t *typeExpr) bool {
+	switch t.kind {
+	case "list", "map", "bytes":
+		return false
+	case "array":
+		return f.comparable(t.elems[0])
+	case "ref":
+		if d := f.decls[t.name]; d != nil && d.kind == "struct" {
+			for _, fd := range d.fields {
+				if !f.comparable(fd.typ) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// checkSize reports struct containing itself not through list, map, optional or union, its values would be infinite
+func (f *File) checkSize(d *decl, path map[string]bool) {
+	if path[d.name] {
+		panic(fmt.Errorf("%s: struct %s contains itself, use optional, list or map", d.pos, d.name))
+	}
+	path[d.name] = true
+	for _, fd := range d.fields {
+		t := fd.typ
+		for t.kind == "array" {
+			t = t.elems[0]
+		}
+		if inner := f.decls[t.name]; t.kind == "ref" && inner != nil && inner.kind == "struct" {
+			f.checkSize(inner, path)
+		}
+	}
+	delete(path, d.name)
+}
+
+func isContainer(name string) bool {
+	return name == "list" || name == "map" || name == "optional" || name == "array"
+}
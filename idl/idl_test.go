@@ -0,0 +1,195 @@
+package idl_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niubaoshu/gotiny"
+	"github.com/niubaoshu/gotiny/idl"
+)
+
+const source = `
+package "github.com/niubaoshu/gotiny/idl_test"
+
+// Node is declared by go type below
+struct Node {
+	2: Name   string
+	1: ID     uint64
+	3: Tags   list<string>
+	4: Attrs  map<string, int32>
+	5: Parent optional<Node>
+	6: Data   bytes
+	7: At     time
+	8: Grid   array<3, float32>
+}
+
+union Shape { Circle | Square }
+
+struct Circle { 1: Radius float64 }
+struct Square { 1: Side float64 }
+
+struct Drawing {
+	1: Shapes list<Shape>
+}
+`
+
+type Node struct {
+	ID     uint64
+	Name   string
+	Tags   []string
+	Attrs  map[string]int32
+	Parent *Node
+	Data   []byte
+	At     time.Time
+	Grid   [3]float32
+}
+
+func parse(t *testing.T) *idl.File {
+	f, err := idl.Parse([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParse(t *testing.T) {
+	f := parse(t)
+	if exp := []string{"Node", "Shape", "Circle", "Square", "Drawing"}; !reflect.DeepEqual(f.Names, exp) {
+		t.Errorf("expected names %v, got %v", exp, f.Names)
+	}
+	if f.Type("Missing") != nil {
+		t.Error("expected nil scheme of undeclared type")
+	}
+	shape := f.Type("Shape").AsJSON()
+	if exp := `"impls":["github.com/niubaoshu/gotiny/idl_test.Circle","github.com/niubaoshu/gotiny/idl_test.Square"]`; !strings.Contains(shape, exp) {
+		t.Errorf("%s doesn't contain %s", shape, exp)
+	}
+
+	scheme, err := f.Scheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(scheme.Childs); l != 4 {
+		t.Errorf("expected all 4 structs, got %d", l)
+	}
+	if _, err = f.Scheme("Node", "Missing"); err == nil {
+		t.Error("expected error for undeclared type")
+	}
+}
+
+func TestDecodeGoData(t *testing.T) {
+	scheme, err := parse(t).Scheme("Node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := Node{
+		ID: 7, Name: "root", Tags: []string{"a", "b"}, Attrs: map[string]int32{"x": -1},
+		Parent: &Node{ID: 1, Data: []byte{1, 2}, At: time.Unix(0, 0)}, At: time.Unix(1500000000, 5), Grid: [3]float32{1, 2, 3},
+	}
+	coder := gotiny.New(Node{})
+	data := coder.Encode(&node)
+
+	got, err := gotiny.DecodeDynamic(scheme, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := gotiny.DecodeDynamic(coder.GetScheme(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if report := gotiny.CheckCompatibility(scheme, coder.GetScheme()); report.Breaking() {
+		t.Errorf("expected compatible schemes: %v", report)
+	}
+
+	encoded, err := gotiny.EncodeDynamic(scheme, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Node
+	coder.Decode(encoded, &decoded)
+	if !reflect.DeepEqual(decoded, node) {
+		t.Errorf("expected %v, got %v", node, decoded)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	scheme, err := parse(t).Scheme("Drawing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := []gotiny.Value{map[string]gotiny.Value{"Shapes": []gotiny.Value{
+		gotiny.InterfaceValue{Type: "github.com/niubaoshu/gotiny/idl_test.Square", Value: map[string]gotiny.Value{"Side": 2.0}},
+		nil,
+		gotiny.InterfaceValue{Type: "github.com/niubaoshu/gotiny/idl_test.Circle", Value: map[string]gotiny.Value{"Radius": 1.5}},
+	}}}
+	data, err := gotiny.EncodeDynamic(scheme, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gotiny.DecodeDynamic(scheme, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, value) {
+		t.Errorf("expected %v, got %v", value, got)
+	}
+
+	src, err := scheme.GoSource("model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"type Shape interface{}",
+		`gotiny.RegisterName("github.com/niubaoshu/gotiny/idl_test.Circle", reflect.TypeOf((*Circle)(nil)).Elem())`,
+	} {
+		if !strings.Contains(string(src), exp) {
+			t.Errorf("%s doesn't contain %s", src, exp)
+		}
+	}
+}
+
+// TestUnionDeclaredLater checks field refers to union with its members when union is declared after field
+func TestUnionDeclaredLater(t *testing.T) {
+	for _, src := range []string{
+		"package p\nstruct R { 1: S Shape }\nunion Shape { C }\nstruct C { 1: X int }",
+		"package p\nunion Shape { C }\nstruct R { 1: S Shape }\nstruct C { 1: X int }",
+	} {
+		f, err := idl.Parse([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheme, err := f.Scheme("R")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if js := scheme.AsJSON(); !strings.Contains(js, `"impls":["p.C"]`) {
+			t.Errorf("expected impls of union in %s", js)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct{ src, err string }{
+		{`struct A {}`, `1:1: expected "package", got "struct"`},
+		{"package p\nstruct A { 1: X int8 2: Y }", `2:27: expected type, got "}"`},
+		{"package p\nstruct A { 0: X int8 }", "2:12: id of field should be positive number, got 0"},
+		{"package p\nstruct A { 1: X int8; 1: Y int8 }", "2:23: id 1 is used by fields X and Y"},
+		{"package p\nstruct A { 1: X int8; 2: X int8 }", "2:23: field X is declared twice"},
+		{"package p\nstruct A {}\nunion A { A }", "3:1: A is declared twice"},
+		{"package p\nstruct A { 1: X B }", "2:17: type B is not declared"},
+		{"package p\nstruct A { 1: X array<2, A> }", "2:1: struct A contains itself, use optional, list or map"},
+		{"package p\nstruct A { 1: X map<bytes, int> }", "2:21: key of map can't be list, map, bytes or struct containing them"},
+		{"package p\nunion U { int }", "2:11: member int of union U is not declared struct"},
+		{"package p\nstruct A { 1: X \"str }", "2:17: string is not terminated"},
+		{"package p\nstruct A { 1: X int8 }\nstruct list {}", "3:1: list is name of builtin type"},
+	} {
+		if _, err := idl.Parse([]byte(c.src)); err == nil || err.Error() != c.err {
+			t.Errorf("%q: expected error %q, got %v", c.src, c.err, err)
+		}
+	}
+}
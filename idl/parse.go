@@ -0,0 +1,224 @@
+package idl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type pos struct{ line, col int }
+
+func (p pos) String() string { return strconv.Itoa(p.line) + ":" + strconv.Itoa(p.col) }
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenString
+	tokenPunct // one of { } < > , : ; |
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  pos
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of file"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lex splits src into tokens, comments start with // and last until end of line
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line, col := 1, 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		p := pos{line, col}
+		start := i
+		switch {
+		case c == '\n':
+			i++
+			line, col = line+1, 1
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			col++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.IndexByte("{}<>,:;|", c) >= 0:
+			i++
+			tokens = append(tokens, token{tokenPunct, src[start:i], p})
+		case c >= '0' && c <= '9':
+			for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				i++
+			}
+			tokens = append(tokens, token{tokenInt, src[start:i], p})
+		case isLetter(c):
+			for i < len(src) && (isLetter(src[i]) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, src[start:i], p})
+		case c == '"':
+			end := strings.IndexAny(src[i+1:], "\"\n")
+			if end < 0 || src[i+1+end] != '"' {
+				return nil, fmt.Errorf("%s: string is not terminated", p)
+			}
+			i += end + 2
+			tokens = append(tokens, token{tokenString, src[start+1 : i-1], p})
+		default:
+			return nil, fmt.Errorf("%s: unexpected character %q", p, c)
+		}
+		col += i - start
+	}
+	return append(tokens, token{kind: tokenEOF, pos: pos{line, col}}), nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// typeExpr is type of field, kind is name of primitive type, "list", "map", "optional", "array" or "ref" to declaration
+type typeExpr struct {
+	kind  string
+	name  string // name of declaration for ref
+	len   int    // length of array
+	elems []*typeExpr
+	pos   pos
+}
+
+type field struct {
+	id   int
+	name string
+	typ  *typeExpr
+	pos  pos
+}
+
+type decl struct {
+	kind    string // struct or union
+	name    string
+	fields  []field
+	members []*typeExpr // refs to members of union
+	pos     pos
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// expect reads token of kind, with text when it isn't empty
+func (p *parser) expect(kind tokenKind, text, what string) token {
+	t := p.next()
+	if t.kind != kind || text != "" && t.text != text {
+		panic(fmt.Errorf("%s: expected %s, got %s", t.pos, what, t))
+	}
+	return t
+}
+
+// skip reads optional separator
+func (p *parser) skip(separators string) {
+	if t := p.peek(); t.kind == tokenPunct && strings.Contains(separators, t.text) {
+		p.next()
+	}
+}
+
+// file parses package clause and declarations
+func (p *parser) file() (pkg string, decls []*decl) {
+	p.expect(tokenIdent, "package", `"package"`)
+	t := p.next()
+	if t.kind != tokenIdent && t.kind != tokenString || t.text == "" {
+		panic(fmt.Errorf("%s: expected package path, got %s", t.pos, t))
+	}
+	pkg = t.text
+	p.skip(";")
+	for p.peek().kind != tokenEOF {
+		decls = append(decls, p.decl())
+	}
+	return pkg, decls
+}
+
+func (p *parser) decl() *decl {
+	t := p.next()
+	if t.kind != tokenIdent || t.text != "struct" && t.text != "union" {
+		panic(fmt.Errorf(`%s: expected "struct" or "union", got %s`, t.pos, t))
+	}
+	d := &decl{kind: t.text, pos: t.pos}
+	d.name = p.expect(tokenIdent, "", "name of "+t.text).text
+	p.expect(tokenPunct, "{", `"{"`)
+	for !(p.peek().kind == tokenPunct && p.peek().text == "}") {
+		if d.kind == "struct" {
+			d.fields = append(d.fields, p.field())
+			p.skip(";,")
+		} else {
+			name := p.expect(tokenIdent, "", "member of union")
+			d.members = append(d.members, &typeExpr{kind: "ref", name: name.text, pos: name.pos})
+			p.skip(";,|")
+		}
+	}
+	p.next()
+	p.skip(";")
+	return d
+}
+
+// field parses "id: name type"
+func (p *parser) field() field {
+	t := p.expect(tokenInt, "", "id of field")
+	id, err := strconv.Atoi(t.text)
+	if err != nil || id <= 0 {
+		panic(fmt.Errorf("%s: id of field should be positive number, got %s", t.pos, t.text))
+	}
+	p.expect(tokenPunct, ":", `":"`)
+	name := p.expect(tokenIdent, "", "name of field").text
+	return field{id: id, name: name, typ: p.typ(), pos: t.pos}
+}
+
+func (p *parser) typ() *typeExpr {
+	t := p.expect(tokenIdent, "", "type")
+	typ := &typeExpr{kind: t.text, pos: t.pos}
+	switch t.text {
+	case "list", "optional":
+		p.expect(tokenPunct, "<", `"<"`)
+		typ.elems = []*typeExpr{p.typ()}
+	case "map":
+		p.expect(tokenPunct, "<", `"<"`)
+		key := p.typ()
+		p.expect(tokenPunct, ",", `","`)
+		typ.elems = []*typeExpr{key, p.typ()}
+	case "array":
+		p.expect(tokenPunct, "<", `"<"`)
+		l := p.expect(tokenInt, "", "length of array")
+		var err error
+		if typ.len, err = strconv.Atoi(l.text); err != nil {
+			panic(fmt.Errorf("%s: %v", l.pos, err))
+		}
+		p.expect(tokenPunct, ",", `","`)
+		typ.elems = []*typeExpr{p.typ()}
+	default:
+		if _, ok := primitives[t.text]; !ok {
+			typ.kind, typ.name = "ref", t.text
+		}
+		return typ
+	}
+	p.expect(tokenPunct, ">", `">"`)
+	return typ
+}
@@ -0,0 +1,49 @@
+package gotiny
+
+import "sync"
+
+// StringInterner returns strings of decoded bytes, equal strings may share memory.
+// It's used by decoders from different goroutines, so it must be safe for concurrent use.
+type StringInterner interface {
+	// Intern returns string with content of b, b is part of decoded data and must not be kept
+	Intern(b []byte) string
+}
+
+// Interner is StringInterner keeping at most max strings, strings decoded after it's full are allocated as usual.
+// Strings are kept while interner is used, so it's for small sets of repeated values like codes and names.
+type Interner struct {
+	mu   sync.RWMutex
+	strs map[string]string
+	max  int
+}
+
+// NewInterner creates interner of at most max strings
+func NewInterner(max int) *Interner {
+	return &Interner{strs: make(map[string]string), max: max}
+}
+
+// Intern returns kept string equal to b, keeps new string when interner isn't full
+func (in *Interner) Intern(b []byte) string {
+	in.mu.RLock()
+	s, ok := in.strs[string(b)] // conversion in map index doesn't allocate
+	in.mu.RUnlock()
+	if ok {
+		return s
+	}
+	s = string(b)
+	in.mu.Lock()
+	if kept, ok := in.strs[s]; ok {
+		s = kept
+	} else if len(in.strs) < in.max {
+		in.strs[s] = s
+	}
+	in.mu.Unlock()
+	return s
+}
+
+// Len returns number of kept strings
+func (in *Interner) Len() int {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return len(in.strs)
+}
@@ -0,0 +1,108 @@
+package gotiny_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type internT struct {
+	Country string
+	Status  string
+	Counts  map[string]int
+}
+
+func sameString(a, b string) bool {
+	return len(a) == len(b) && unsafe.StringData(a) == unsafe.StringData(b)
+}
+
+func TestInterner(t *testing.T) {
+	coder := gotiny.New([]internT{})
+	interner := gotiny.NewInterner(3)
+	coder.SetInterner(interner)
+	src := []internT{
+		{Country: "NL", Status: "active", Counts: map[string]int{"NL": 1}},
+		{Country: "NL", Status: "active"},
+		{Country: "DE", Status: "blocked"},
+	}
+	data := coder.Encode(&src)
+	var got []internT
+	coder.Decode(data, &got)
+	Assert(t, data, src, got)
+
+	if !sameString(got[0].Country, got[1].Country) || !sameString(got[0].Status, got[1].Status) {
+		t.Error("expected interned strings to share memory")
+	}
+	for key := range got[0].Counts {
+		if !sameString(key, got[1].Country) {
+			t.Error("expected interned map key")
+		}
+	}
+	// interner keeps NL, active and DE, blocked is allocated
+	if n := interner.Len(); n != 3 {
+		t.Errorf("expected 3 interned strings, got %d", n)
+	}
+	var again []internT
+	coder.Decode(data, &again)
+	if !sameString(got[2].Country, again[2].Country) || sameString(got[2].Status, again[2].Status) {
+		t.Error("expected only strings kept by interner to be shared")
+	}
+
+	// interned strings don't alias data
+	for i := range data {
+		data[i] = 0
+	}
+	if got[0].Country != "NL" {
+		t.Errorf("expected NL, got %q", got[0].Country)
+	}
+}
+
+func TestInternerAllocs(t *testing.T) {
+	coder := gotiny.New(internT{})
+	coder.SetInterner(gotiny.NewInterner(10))
+	src := internT{Country: "NL", Status: "active"}
+	data := coder.Encode(&src)
+	var got internT
+	if allocs := testing.AllocsPerRun(100, func() { coder.Decode(data, &got) }); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestInternerConcurrent(t *testing.T) {
+	interner := gotiny.NewInterner(50)
+	coder := gotiny.New("")
+	coder.SetInterner(interner)
+	var wg sync.WaitGroup
+	results := make([]string, 16)
+	for g := range results {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				s := strconv.Itoa(k)
+				data := gotiny.Marshal(&s)
+				var got string
+				coder.Decode(data, &got)
+				if got != s {
+					t.Errorf("expected %s, got %s", s, got)
+					return
+				}
+				if k == 7 {
+					results[g] = got
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	for _, s := range results {
+		if !sameString(s, results[0]) {
+			t.Error("expected interned strings to share memory")
+		}
+	}
+	if n := interner.Len(); n != 50 {
+		t.Errorf("expected 50 interned strings, got %d", n)
+	}
+}
@@ -0,0 +1,239 @@
+package gotiny
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// jsonSchema is node of JSON Schema document
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"` // name of type or list of names
+	Const                string                 `json:"const,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Minimum              json.Number            `json:"minimum,omitempty"`
+	Maximum              json.Number            `json:"maximum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"` // false or *jsonSchema
+	PrefixItems          []*jsonSchema          `json:"prefixItems,omitempty"`
+	Items                interface{}            `json:"items,omitempty"` // false or *jsonSchema
+	MinItems             int                    `json:"minItems,omitempty"`
+	MaxItems             int                    `json:"maxItems,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+	OneOf                []*jsonSchema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// JSONSchema returns JSON Schema (draft 2020-12) of JSON written by ToJSON for data encoded with scheme s,
+// so it can be validated by other tools. Named structs, slices, arrays, maps, pointers and interfaces are described in $defs.
+// Interface values are constrained to types implementing interface which are registered at the moment.
+func (s *Scheme) JSONSchema() ([]byte, error) {
+	w := jsonSchemaWriter{names: map[string]string{}, used: map[string]bool{}, defs: map[string]*jsonSchema{}}
+	var root *jsonSchema
+	var err error
+	if s.isRoot() {
+		// top level values of coder are array
+		items := make([]*jsonSchema, len(s.Childs))
+		for i, child := range s.Childs {
+			if items[i], err = w.node(child); err != nil {
+				return nil, err
+			}
+		}
+		root = &jsonSchema{Type: "array", PrefixItems: items, Items: false, MinItems: len(items)}
+	} else if root, err = w.node(s); err != nil {
+		return nil, err
+	}
+	if len(w.defs) > 0 {
+		root.Defs = w.defs
+	}
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	return json.MarshalIndent(root, "", "  ")
+}
+
+type jsonSchemaWriter struct {
+	names map[string]string // names in $defs by go types, nodes without go type are identified by address
+	used  map[string]bool
+	defs  map[string]*jsonSchema
+}
+
+// node describes named composite types and interfaces in $defs and returns references to them, so recursive types are described too
+func (w *jsonSchemaWriter) node(s *Scheme) (*jsonSchema, error) {
+	name := namedGoType(s.GoType)
+	switch s.Type {
+	case typeStruct, typePointer, typeArray, typeSlice, typeMap:
+	case typeInterface:
+		// implementations may contain the same interface
+		if name == "" {
+			name = "Interface"
+		}
+	default:
+		name = ""
+	}
+	if name == "" {
+		return w.literal(s)
+	}
+	key := s.GoType
+	if key == "" {
+		key = fmt.Sprintf("%p", s)
+	}
+	if def, ok := w.names[key]; ok {
+		return &jsonSchema{Ref: "#/$defs/" + def}, nil
+	}
+	def := name
+	for i := 2; w.used[def]; i++ {
+		def = name + strconv.Itoa(i)
+	}
+	w.used[def] = true
+	w.names[key] = def
+	node, err := w.literal(s)
+	if err != nil {
+		return nil, err
+	}
+	node.Title = s.GoType
+	w.defs[def] = node
+	return &jsonSchema{Ref: "#/$defs/" + def}, nil
+}
+
+// literal describes node without reference to $defs
+func (w *jsonSchemaWriter) literal(s *Scheme) (*jsonSchema, error) {
+	switch s.Type {
+	case typeIgnore:
+		if s.unknownKind() {
+			return nil, s.unknownKindError()
+		}
+		return &jsonSchema{Type: "null"}, nil
+	case typeBool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case typeInt, typeInt64:
+		return intSchema(math.MinInt64, math.MaxInt64), nil
+	case typeInt8:
+		return intSchema(math.MinInt8, math.MaxInt8), nil
+	case typeInt16:
+		return intSchema(math.MinInt16, math.MaxInt16), nil
+	case typeInt32:
+		return intSchema(math.MinInt32, math.MaxInt32), nil
+	case typeUint, typeUint64:
+		return &jsonSchema{Type: "integer", Minimum: "0", Maximum: json.Number(strconv.FormatUint(math.MaxUint64, 10))}, nil
+	case typeUint8:
+		return intSchema(0, math.MaxUint8), nil
+	case typeUint16:
+		return intSchema(0, math.MaxUint16), nil
+	case typeUint32:
+		return intSchema(0, math.MaxUint32), nil
+	case typeFloat32, typeFloat64:
+		return floatSchema(), nil
+	case typeComplex64, typeComplex128:
+		return &jsonSchema{Type: "array", PrefixItems: []*jsonSchema{floatSchema(), floatSchema()}, Items: false, MinItems: 2}, nil
+	case typeString:
+		return &jsonSchema{Type: "string"}, nil
+	case typeBytes:
+		if s.ambiguousBytes() {
+			return nil, s.ambiguousBytesError()
+		}
+		if s.isStringNode() {
+			return &jsonSchema{Type: "string"}, nil
+		}
+		return &jsonSchema{Type: []string{"string", "null"}, ContentEncoding: "base64"}, nil
+	case typeTime:
+		return &jsonSchema{Type: "string", Format: "date-time"}, nil
+	case typeStruct:
+		return w.structNode(s)
+	case typePointer:
+		elem, err := w.node(s.child(0))
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{AnyOf: []*jsonSchema{elem, {Type: "null"}}}, nil
+	case typeArray, typeSlice:
+		if s.unknownLen() {
+			return nil, s.unknownLenError()
+		}
+		elem, err := w.node(s.child(0))
+		if err != nil {
+			return nil, err
+		}
+		if s.Type == typeArray {
+			return &jsonSchema{Type: "array", Items: elem, MinItems: s.Len, MaxItems: s.Len}, nil
+		}
+		return &jsonSchema{Type: []string{"array", "null"}, Items: elem}, nil
+	case typeMap:
+		key, err := w.node(s.child(0))
+		if err != nil {
+			return nil, err
+		}
+		value, err := w.node(s.child(1))
+		if err != nil {
+			return nil, err
+		}
+		if s.child(0).isStringNode() {
+			return &jsonSchema{Type: []string{"object", "null"}, AdditionalProperties: value}, nil
+		}
+		pair := &jsonSchema{Type: "array", PrefixItems: []*jsonSchema{key, value}, Items: false, MinItems: 2}
+		return &jsonSchema{Type: []string{"array", "null"}, Items: pair}, nil
+	case typeInterface:
+		return w.interfaceNode(s)
+	case typeCustom:
+		return &jsonSchema{Description: "value of " + s.GoType + " with custom encoding written by encoding/json"}, nil
+	}
+	return nil, errors.New("gotiny: unknown scheme type " + s.Type.String())
+}
+
+func (w *jsonSchemaWriter) structNode(s *Scheme) (*jsonSchema, error) {
+	node := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}, Required: []string{}, AdditionalProperties: false}
+	for _, child := range s.Childs {
+		field, err := w.node(child)
+		if err != nil {
+			return nil, err
+		}
+		node.Properties[child.Name] = field
+		node.Required = append(node.Required, child.Name)
+	}
+	return node, nil
+}
+
+// interfaceNode describes value of one of registered implementations, or any value when none is known
+func (w *jsonSchemaWriter) interfaceNode(s *Scheme) (*jsonSchema, error) {
+	node := &jsonSchema{OneOf: []*jsonSchema{{Type: "null"}}}
+	impls := s.impls()
+	for _, impl := range impls {
+		value := &jsonSchema{}
+		if implScheme, ok := s.implScheme(impl); ok {
+			var err error
+			if value, err = w.node(implScheme); err != nil {
+				return nil, err
+			}
+		}
+		node.OneOf = append(node.OneOf, interfaceSchema(&jsonSchema{Type: "string", Const: impl}, value))
+	}
+	if len(impls) == 0 {
+		node.OneOf = append(node.OneOf, interfaceSchema(&jsonSchema{Type: "string"}, &jsonSchema{}))
+	}
+	return node, nil
+}
+
+func interfaceSchema(name, value *jsonSchema) *jsonSchema {
+	return &jsonSchema{
+		Type:                 "object",
+		Properties:           map[string]*jsonSchema{"type": name, "value": value},
+		Required:             []string{"type", "value"},
+		AdditionalProperties: false,
+	}
+}
+
+func intSchema(min, max int64) *jsonSchema {
+	return &jsonSchema{Type: "integer", Minimum: json.Number(strconv.FormatInt(min, 10)), Maximum: json.Number(strconv.FormatInt(max, 10))}
+}
+
+// floatSchema describes number, NaN and infinities are strings
+func floatSchema() *jsonSchema {
+	return &jsonSchema{AnyOf: []*jsonSchema{{Type: "number"}, {Enum: []string{"NaN", "+Inf", "-Inf"}}}}
+}
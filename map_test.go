@@ -0,0 +1,40 @@
+package gotiny_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+func TestMapAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations of pooled scratch of maps aren't stable with race detector")
+	}
+	src := make(map[uint32]float64, 100)
+	for i := uint32(0); i < 100; i++ {
+		src[i*7] = float64(i) / 3
+	}
+	e := gotiny.NewEncoderWithPtr(&src)
+	data := e.Encode(&src)
+	if n := testing.AllocsPerRun(100, func() { e.Encode(&src) }); n != 0 {
+		t.Errorf("expected no allocations on encode, got %v", n)
+	}
+
+	d := gotiny.NewDecoderWithPtr(&src)
+	m := map[uint32]float64{}
+	d.Decode(data, &m)
+	if n := testing.AllocsPerRun(100, func() { d.Decode(data, &m) }); n != 0 {
+		t.Errorf("expected no allocations on decode into map, got %v", n)
+	}
+	// only new map is allocated
+	rt := reflect.TypeOf(src)
+	exp := testing.AllocsPerRun(100, func() { reflect.MakeMapWithSize(rt, len(src)) })
+	p := new(map[uint32]float64)
+	if n := testing.AllocsPerRun(100, func() {
+		*p = nil
+		d.Decode(data, p)
+	}); n != exp {
+		t.Errorf("expected %v allocations of map on decode, got %v", exp, n)
+	}
+}
@@ -0,0 +1,257 @@
+package gotiny
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ToMsgpack converts buf encoded with scheme into MessagePack.
+// Structs are maps keyed by field names, []byte is binary, time is timestamp extension type -1,
+// nil pointers, slices, maps and []byte are nil, complex numbers are arrays [real, imag],
+// interfaces are maps {"type": name, "value": value} and values with custom encoding
+// are binary with bytes written by gotiny, their types should be registered.
+// Scheme of coder produces array of top level values.
+func ToMsgpack(scheme *Scheme, buf []byte) ([]byte, error) {
+	w := &msgpackWriter{}
+	if err := transcodeTo(w, scheme, buf); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// FromMsgpack converts MessagePack in form written by ToMsgpack into bytes encoded with scheme.
+// Numbers are checked to fit into types of scheme, strings are accepted for []byte too.
+func FromMsgpack(scheme *Scheme, data []byte) ([]byte, error) {
+	return transcodeFrom(&msgpackReader{buf: data[:len(data):len(data)]}, scheme)
+}
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) writeNil() { w.buf = append(w.buf, 0xc0) }
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(i))
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		w.buf = append(w.buf, 0xd1, byte(i>>8), byte(i))
+	case i >= math.MinInt32:
+		w.buf = append(w.buf, 0xd2)
+		w.buf = appendUint32(w.buf, uint32(i))
+	default:
+		w.buf = append(w.buf, 0xd3)
+		w.buf = appendUint64(w.buf, uint64(i))
+	}
+}
+
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u < 1<<7:
+		w.buf = append(w.buf, byte(u))
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = append(w.buf, 0xcd, byte(u>>8), byte(u))
+	case u <= math.MaxUint32:
+		w.buf = append(w.buf, 0xce)
+		w.buf = appendUint32(w.buf, uint32(u))
+	default:
+		w.buf = append(w.buf, 0xcf)
+		w.buf = appendUint64(w.buf, u)
+	}
+}
+
+func (w *msgpackWriter) writeFloat(f float64, bits int) {
+	if bits == 32 {
+		w.buf = append(w.buf, 0xca)
+		w.buf = appendUint32(w.buf, math.Float32bits(float32(f)))
+	} else {
+		w.buf = append(w.buf, 0xcb)
+		w.buf = appendUint64(w.buf, math.Float64bits(f))
+	}
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	w.header(len(s), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) writeBytes(b []byte) {
+	w.header(len(b), 0, 0, 0xc4, 0xc5, 0xc6)
+	w.buf = append(w.buf, b...)
+}
+
+func (w *msgpackWriter) writeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint32(t.Nanosecond())
+	switch {
+	case sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		w.buf = append(w.buf, 0xd6, 0xff)
+		w.buf = appendUint32(w.buf, uint32(sec))
+	case sec>>34 == 0:
+		w.buf = append(w.buf, 0xd7, 0xff)
+		w.buf = appendUint64(w.buf, uint64(nsec)<<34|uint64(sec))
+	default:
+		w.buf = append(w.buf, 0xc7, 12, 0xff)
+		w.buf = appendUint32(w.buf, nsec)
+		w.buf = appendUint64(w.buf, uint64(sec))
+	}
+}
+
+func (w *msgpackWriter) writeArray(n int) { w.header(n, 0x90, 16, 0, 0xdc, 0xdd) }
+
+func (w *msgpackWriter) writeMap(n int) { w.header(n, 0x80, 16, 0, 0xde, 0xdf) }
+
+// header writes length n in fix format if n < fixMax, otherwise in format with 1, 2 or 4 bytes of length,
+// format with 1 byte is 0 for arrays and maps which don't have it
+func (w *msgpackWriter) header(n int, fix byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case n < fixMax:
+		w.buf = append(w.buf, fix|byte(n))
+	case n <= math.MaxUint8 && code8 != 0:
+		w.buf = append(w.buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, code16, byte(n>>8), byte(n))
+	default:
+		w.buf = append(w.buf, code32)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+}
+
+func appendUint32(buf []byte, u uint32) []byte {
+	return append(buf, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(buf []byte, u uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(u>>32)), uint32(u))
+}
+
+type msgpackReader struct {
+	buf []byte
+	i   int
+}
+
+func (r *msgpackReader) rest() int { return len(r.buf) - r.i }
+
+// next returns next n bytes
+func (r *msgpackReader) next(n int) []byte {
+	r.i += n
+	return r.buf[r.i-n : r.i]
+}
+
+// length reads big endian length of size bytes
+func (r *msgpackReader) length(size int) int {
+	var n uint64
+	for _, b := range r.next(size) {
+		n = n<<8 | uint64(b)
+	}
+	return readLength(n, r.rest())
+}
+
+func (r *msgpackReader) read() interface{} {
+	c := r.next(1)[0]
+	switch {
+	case c < 0x80:
+		return uint64(c)
+	case c >= 0xe0:
+		return int64(int8(c))
+	case c&0xf0 == 0x80:
+		return r.readMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return r.readArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return string(r.next(int(c & 0x1f)))
+	}
+	switch c {
+	case 0xc0:
+		return nil
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	case 0xc4, 0xc5, 0xc6:
+		return append([]byte{}, r.next(r.length(1<<(c-0xc4)))...)
+	case 0xc7, 0xc8, 0xc9:
+		n := r.length(1 << (c - 0xc7))
+		return r.readExt(n)
+	case 0xca:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(r.next(4))))
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(r.next(8)))
+	case 0xcc:
+		return uint64(r.next(1)[0])
+	case 0xcd:
+		return uint64(binary.BigEndian.Uint16(r.next(2)))
+	case 0xce:
+		return uint64(binary.BigEndian.Uint32(r.next(4)))
+	case 0xcf:
+		return binary.BigEndian.Uint64(r.next(8))
+	case 0xd0:
+		return int64(int8(r.next(1)[0]))
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(r.next(2))))
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(r.next(4))))
+	case 0xd3:
+		return int64(binary.BigEndian.Uint64(r.next(8)))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.readExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		return string(r.next(r.length(1 << (c - 0xd9))))
+	case 0xdc, 0xdd:
+		return r.readArray(r.length(2 << (c - 0xdc)))
+	case 0xde, 0xdf:
+		return r.readMap(r.length(2 << (c - 0xde)))
+	}
+	panic(dynamicError{fmt.Errorf("gotiny: corrupted data: unknown MessagePack format 0x%02x", c)})
+}
+
+func (r *msgpackReader) readArray(n int) []interface{} {
+	elems := make([]interface{}, readLength(uint64(n), r.rest()))
+	for i := range elems {
+		elems[i] = r.read()
+	}
+	return elems
+}
+
+func (r *msgpackReader) readMap(n int) []MapEntry {
+	entries := make([]MapEntry, readLength(uint64(n), r.rest()))
+	for i := range entries {
+		key := r.read()
+		entries[i] = MapEntry{Key: key, Value: r.read()}
+	}
+	return entries
+}
+
+// readExt reads extension value of n bytes, only timestamp extension is supported
+func (r *msgpackReader) readExt(n int) time.Time {
+	if typ := int8(r.next(1)[0]); typ != -1 {
+		panic(dynamicError{fmt.Errorf("gotiny: unsupported MessagePack extension type %d", typ)})
+	}
+	data := r.next(n)
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34))
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data)))
+	}
+	panic(dynamicError{fmt.Errorf("gotiny: corrupted data: timestamp of %d bytes", n)})
+}
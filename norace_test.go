@@ -0,0 +1,5 @@
+//go:build !race
+
+package gotiny_test
+
+const raceEnabled = false
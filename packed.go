@@ -0,0 +1,236 @@
+package gotiny
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// littleEndian reports whether memory of numbers is in little endian order, packed numbers are copied as is then
+var littleEndian = func() bool {
+	u := uint16(1)
+	return *(*byte)(unsafe.Pointer(&u)) == 1
+}()
+
+// packedField reports whether field is tagged with `gotiny:"packed"`
+func packedField(field reflect.StructField) bool {
+	tinyTag, ok := field.Tag.Lookup("gotiny")
+	return ok && strings.TrimSpace(tinyTag) == "packed"
+}
+
+// packedSize returns size of packed number of type t, 0 if numbers of t can't be packed.
+// int and uint are not packed, their size depends on platform.
+func packedSize(t gotinyType) int {
+	switch t {
+	case typeInt8, typeUint8:
+		return 1
+	case typeInt16, typeUint16:
+		return 2
+	case typeInt32, typeUint32, typeFloat32:
+		return 4
+	case typeInt64, typeUint64, typeFloat64, typeComplex64:
+		return 8
+	case typeComplex128:
+		return 16
+	}
+	return 0
+}
+
+// packedWord returns size of little endian words of packed number, parts of complex numbers are separate words
+func packedWord(t gotinyType) int {
+	if t == typeComplex64 || t == typeComplex128 {
+		return packedSize(t) / 2
+	}
+	return packedSize(t)
+}
+
+// checkPacked panics when field of type rt can't be packed, it's checked before node of struct is cached
+func checkPacked(rt reflect.Type, field string) {
+	if k := rt.Kind(); k == reflect.Slice || k == reflect.Array {
+		switch rt.Elem().Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+			return
+		}
+	}
+	panic(packedError(rt, field))
+}
+
+func packedError(rt reflect.Type, field string) string {
+	return "gotiny: field " + field + " of type " + rt.String() + " can't be packed, only slices and arrays of sized numbers can"
+}
+
+// setPacked makes node of field tagged with `gotiny:"packed"` packed:
+// numbers of slice or array are written as block of fixed size little endian numbers instead of varints
+func (s *Scheme) setPacked(rt reflect.Type, field string) {
+	if s.Type != typeSlice && s.Type != typeArray && s.Type != typeBytes || len(s.Childs) == 1 && packedSize(s.Childs[0].Type) == 0 {
+		panic(packedError(rt, field))
+	}
+	if s.Type == typeBytes {
+		return // []byte is copied as is anyway
+	}
+	s.Packed = true
+	s.setPackedEngines(rt)
+}
+
+func (s *Scheme) setPackedEngines(rt reflect.Type) {
+	size, word := packedSize(s.Childs[0].Type), packedWord(s.Childs[0].Type)
+	if s.Type == typeArray {
+		n := rt.Len() * size
+		s.encodeEngine = func(e *Encoder, p unsafe.Pointer) { e.encPacked(p, n, word) }
+		s.decodeEngine = func(d *Decoder, p unsafe.Pointer) { d.decPacked(p, n, word) }
+		s.sizeEngine = func(e *Encoder, p unsafe.Pointer) { e.size += n }
+		return
+	}
+	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
+		isNotNil := !isNil(p)
+		e.encIsNotNil(isNotNil)
+		if isNotNil {
+			header := (*sliceHeader)(p)
+			e.encLength(header.len)
+			e.encPacked(header.data, header.len*size, word)
+		}
+	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		e.sizeBool()
+		if !isNil(p) {
+			l := (*sliceHeader)(p).len
+			e.sizeLength(l)
+			e.size += l * size
+		}
+	}
+	s.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
+		header := (*sliceHeader)(p)
+		if d.decIsNotNil() {
+			l := d.decLength()
+			_ = d.rest()[:l*size] // corrupted length shouldn't allocate more than data has
+			if isNil(p) || header.cap < l {
+				*header = sliceHeader{d.makeSlice(rt, l), l, l}
+			} else {
+				header.len = l
+			}
+			d.decPacked(header.data, l*size, word)
+		} else if !isNil(p) {
+			*header = sliceHeader{}
+		}
+	}
+}
+
+// encPacked appends n bytes of numbers at p in little endian order
+func (e *Encoder) encPacked(p unsafe.Pointer, n, word int) {
+	if n == 0 {
+		return
+	}
+	start := len(e.buf)
+	e.buf = append(e.buf, unsafe.Slice((*byte)(p), n)...)
+	if !littleEndian {
+		reverseWords(e.buf[start:], word)
+	}
+}
+
+// decPacked copies n bytes of little endian numbers to p
+func (d *Decoder) decPacked(p unsafe.Pointer, n, word int) {
+	if n == 0 {
+		return
+	}
+	b := unsafe.Slice((*byte)(p), n)
+	d.index += copy(b, d.rest()[:n])
+	if !littleEndian {
+		reverseWords(b, word)
+	}
+}
+
+func reverseWords(b []byte, word int) {
+	for i := 0; i < len(b); i += word {
+		w := b[i : i+word]
+		for j, k := 0, word-1; j < k; j, k = j+1, k-1 {
+			w[j], w[k] = w[k], w[j]
+		}
+	}
+}
+
+// decDynamicElem decodes element of slice or array node s
+func (d *Decoder) decDynamicElem(s *Scheme) Value {
+	if !s.Packed {
+		return d.decDynamic(s.child(0))
+	}
+	t := s.child(0).Type
+	size := packedSize(t)
+	if size == 0 {
+		panic(dynamicError{errors.New("gotiny: elements of type " + t.String() + " can't be packed")})
+	}
+	b := d.rest()[:size]
+	d.index += size
+	u := littleEndianUint(b)
+	switch t {
+	case typeInt8:
+		return int64(int8(u))
+	case typeInt16:
+		return int64(int16(u))
+	case typeInt32:
+		return int64(int32(u))
+	case typeInt64:
+		return int64(u)
+	case typeFloat32:
+		return float64(math.Float32frombits(uint32(u)))
+	case typeFloat64:
+		return math.Float64frombits(u)
+	case typeComplex64:
+		return complex128(complex(math.Float32frombits(uint32(u)), math.Float32frombits(uint32(u>>32))))
+	case typeComplex128:
+		return complex(math.Float64frombits(u), math.Float64frombits(littleEndianUint(b[8:])))
+	}
+	return u
+}
+
+// encDynamicElem encodes element of slice or array node s
+func (e *Encoder) encDynamicElem(s *Scheme, v interface{}, path string) {
+	if !s.Packed {
+		e.encDynamic(s.child(0), v, path)
+		return
+	}
+	t := s.child(0).Type
+	size := packedSize(t)
+	var u uint64
+	switch t {
+	case typeInt8, typeInt16, typeInt32, typeInt64:
+		u = uint64(dynamicInt(v, uint(size*8), path))
+	case typeUint8, typeUint16, typeUint32, typeUint64:
+		u = dynamicUint(v, uint(size*8), path)
+	case typeFloat32:
+		u = uint64(math.Float32bits(float32(dynamicFloat(v, path))))
+	case typeFloat64:
+		u = math.Float64bits(dynamicFloat(v, path))
+	case typeComplex64:
+		c := complex64(dynamicComplex(v, path))
+		u = uint64(math.Float32bits(real(c))) | uint64(math.Float32bits(imag(c)))<<32
+	case typeComplex128:
+		c := dynamicComplex(v, path)
+		e.buf = appendLittleEndian(e.buf, math.Float64bits(real(c)), 8)
+		u, size = math.Float64bits(imag(c)), 8
+	default:
+		panic(dynamicErrorf(path, "elements of type %s can't be packed", t))
+	}
+	e.buf = appendLittleEndian(e.buf, u, size)
+}
+
+// littleEndianUint returns number of at most 8 first bytes of b
+func littleEndianUint(b []byte) (u uint64) {
+	if len(b) > 8 {
+		b = b[:8]
+	}
+	for i := len(b) - 1; i >= 0; i-- {
+		u = u<<8 | uint64(b[i])
+	}
+	return u
+}
+
+// appendLittleEndian appends size lowest bytes of u
+func appendLittleEndian(buf []byte, u uint64, size int) []byte {
+	for i := 0; i < size; i++ {
+		buf = append(buf, byte(u>>(8*i)))
+	}
+	return buf
+}
@@ -0,0 +1,185 @@
+package gotiny_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type packedT struct {
+	F   []float64     `gotiny:"packed"`
+	F32 []float32     `gotiny:"packed"`
+	U   [3]uint32     `gotiny:"packed"`
+	I   []int16       `gotiny:"packed"`
+	I8  []int8        `gotiny:"packed"`
+	U64 []uint64      `gotiny:"packed"`
+	C   []complex64   `gotiny:"packed"`
+	C2  [1]complex128 `gotiny:"packed"`
+	B   []byte        `gotiny:"packed"`
+	N   []float64     `gotiny:"packed"`
+	E   []int32       `gotiny:"packed"`
+	V   []float64
+}
+
+var packedV = packedT{
+	F:   []float64{0.1, -1, math.MaxFloat64, math.Inf(1)},
+	F32: []float32{1.5},
+	U:   [3]uint32{1, math.MaxUint32, 300},
+	I:   []int16{-1, math.MinInt16, math.MaxInt16},
+	I8:  []int8{-128, 127},
+	U64: []uint64{math.MaxUint64, 0},
+	C:   []complex64{complex(1, -2)},
+	C2:  [1]complex128{complex(0.1, 3)},
+	B:   []byte{1, 2},
+	E:   []int32{},
+	V:   []float64{0.1},
+}
+
+func TestPacked(t *testing.T) {
+	coder := gotiny.New(packedT{})
+	data := coder.Encode(&packedV)
+	var got packedT
+	coder.Decode(data, &got)
+	Assert(t, data, packedV, got)
+
+	// decoding into slices with capacity reuses them
+	f := got.F
+	coder.Decode(data, &got)
+	if &f[0] != &got.F[0] {
+		t.Error("expected reused slice")
+	}
+	got.N = []float64{1}
+	coder.Decode(data, &got)
+	if got.N != nil {
+		t.Errorf("expected nil slice, got %v", got.N)
+	}
+}
+
+func TestPackedBytes(t *testing.T) {
+	type small struct {
+		F []float32 `gotiny:"packed"`
+		A [2]uint16 `gotiny:"packed"`
+		C []int64   `gotiny:"packed"`
+	}
+	data := gotiny.Marshal(&small{F: []float32{1}, A: [2]uint16{1, 0x0203}})
+	// not nil bits of F and C, length of F, little endian numbers
+	exp := []byte{0x01, 0x01, 0x00, 0x00, 0x80, 0x3f, 0x01, 0x00, 0x03, 0x02}
+	if !reflect.DeepEqual(data, exp) {
+		t.Errorf("expected % x, got % x", exp, data)
+	}
+
+	// truncated data and length bigger than data panic, latter before allocation
+	for _, buf := range [][]byte{data[:len(data)-1], {0x01, 0x7f, 0, 0, 0, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("% x: expected panic", buf)
+				}
+			}()
+			gotiny.Unmarshal(buf, &small{})
+		}()
+	}
+}
+
+func TestPackedDynamic(t *testing.T) {
+	coder := gotiny.New(packedT{})
+	data := coder.Encode(&packedV)
+	js := coder.GetScheme().AsJSON()
+	if exp := `"name":"F","type":"slice","goType":"[]float64","packed":true`; !strings.Contains(js, exp) {
+		t.Errorf("%s doesn't contain %s", js, exp)
+	}
+	scheme, err := gotiny.SchemeFromJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := gotiny.DecodeDynamic(scheme, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := value.([]gotiny.Value)[0].(map[string]gotiny.Value)
+	if exp := []gotiny.Value{int64(-1), int64(math.MinInt16), int64(math.MaxInt16)}; !reflect.DeepEqual(fields["I"], exp) {
+		t.Errorf("expected %v, got %v", exp, fields["I"])
+	}
+	if exp := []gotiny.Value{complex128(complex(1, -2))}; !reflect.DeepEqual(fields["C"], exp) {
+		t.Errorf("expected %v, got %v", exp, fields["C"])
+	}
+	encoded, err := gotiny.EncodeDynamic(scheme, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, encoded) {
+		t.Errorf("expected\n%v\ngot\n%v", data, encoded)
+	}
+
+	json, err := gotiny.ToJSON(scheme, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := gotiny.FromJSON(scheme, json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, back) {
+		t.Errorf("expected\n%v\ngot\n%v", data, back)
+	}
+
+	binary, err := coder.GetScheme().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary gotiny.Scheme
+	if err := fromBinary.UnmarshalBinary(binary); err != nil {
+		t.Fatal(err)
+	}
+	if got := fromBinary.AsJSON(); got != js {
+		t.Errorf("expected scheme\n%s\ngot\n%s", js, got)
+	}
+
+	annotations, err := gotiny.Annotate(scheme, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range annotations {
+		if a.Path == "[0].F32[0]" && (a.What != "packed float32" || a.Len != 4 || a.Value != 1.5) {
+			t.Errorf("unexpected annotation %+v", a)
+		}
+	}
+}
+
+func TestPackedCompatibility(t *testing.T) {
+	type unpacked struct{ F []float64 }
+	type packed struct {
+		F []float64 `gotiny:"packed"`
+	}
+	report := gotiny.CheckCompatibility(gotiny.New(unpacked{}).GetScheme(), gotiny.New(packed{}).GetScheme())
+	if len(report.Changes) != 1 || report.Changes[0].Kind != gotiny.TypeChanged || !report.Breaking() {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestPackedUnsupported(t *testing.T) {
+	type strs struct {
+		S []string `gotiny:"packed"`
+	}
+	type ints struct {
+		I []int `gotiny:"packed"`
+	}
+	type num struct {
+		F float64 `gotiny:"packed"`
+	}
+	// strs is repeated, type failed to build isn't cached
+	for _, v := range []interface{}{strs{}, ints{}, num{}, strs{}} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), "can't be packed") {
+					t.Errorf("%T: expected panic, got %v", v, r)
+				}
+			}()
+			gotiny.New(v)
+		}()
+	}
+
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package gotiny_test
+
+// raceEnabled is set when tests run with race detector, sync.Pool drops entries randomly then,
+// so pooled scratch of maps is allocated again and allocations can't be counted
+const raceEnabled = true
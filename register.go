@@ -2,12 +2,15 @@ package gotiny
 
 import (
 	"reflect"
+	"sort"
 	"strconv"
+	"sync"
 )
 
 var (
-	type2name = map[reflect.Type]string{}
-	name2type = map[string]reflect.Type{}
+	type2name    = map[reflect.Type]string{}
+	name2type    = map[string]reflect.Type{}
+	registerLock sync.RWMutex // guards type2name and name2type, types are registered while encoding
 )
 
 // GetName returns string representation of object type
@@ -109,10 +112,28 @@ func getName(prefix []byte, rt reflect.Type) []byte {
 }
 
 func getNameOfType(rt reflect.Type) string {
-	if name, has := type2name[rt]; has {
+	registerLock.RLock()
+	name, has := type2name[rt]
+	registerLock.RUnlock()
+	if has {
 		return name
 	}
-	return registerType(rt)
+	registerLock.Lock()
+	defer registerLock.Unlock()
+	if name, has := type2name[rt]; has { // registered by another goroutine
+		return name
+	}
+	name = GetNameByType(rt)
+	registerName(name, rt)
+	return name
+}
+
+// typeByName returns type registered with name
+func typeByName(name string) (reflect.Type, bool) {
+	registerLock.RLock()
+	rt, has := name2type[name]
+	registerLock.RUnlock()
+	return rt, has
 }
 
 // Register allow to add type so system will be encode and decode it from interface{}
@@ -128,6 +149,12 @@ func registerType(rt reflect.Type) string {
 
 // RegisterName allow to register type with name provided
 func RegisterName(name string, rt reflect.Type) {
+	registerLock.Lock()
+	defer registerLock.Unlock()
+	registerName(name, rt)
+}
+
+func registerName(name string, rt reflect.Type) {
 	if name == "" {
 		panic("attempt to register empty name")
 	}
@@ -146,3 +173,17 @@ func RegisterName(name string, rt reflect.Type) {
 	name2type[name] = rt
 	type2name[rt] = name
 }
+
+// registeredImplementations returns sorted names of registered types implementing interface it
+func registeredImplementations(it reflect.Type) []string {
+	var names []string
+	registerLock.RLock()
+	defer registerLock.RUnlock()
+	for name, rt := range name2type {
+		if rt.Implements(it) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,6 +2,10 @@ package gotiny
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
 	"unsafe"
 )
 
@@ -10,11 +14,51 @@ type Scheme struct {
 	Name         string `json:"name,omitempty"`
 	encodeEngine encEng
 	decodeEngine decEng
-	Type         gotinyType `json:"type,omitempty"`
-	Childs       []*Scheme  `json:"childs,omitempty"`
-	offset       uintptr    // struct offset to fill object
+	sizeEngine   sizeEng
+	Type         gotinyType   `json:"type,omitempty"`
+	GoType       string       `json:"goType,omitempty"` // name of go type as returned by GetNameByType
+	Len          int          `json:"len,omitempty"`    // length of array
+	Packed       bool         `json:"packed,omitempty"` // numbers of slice or array are fixed size little endian
+	Impls        []string     `json:"impls,omitempty"`  // names of registered types implementing interface
+	Childs       []*Scheme    `json:"childs,omitempty"`
+	offset       uintptr      // struct offset to fill object
+	rt           reflect.Type // type scheme was built from, nil for schemes read from json
+	legacy       bool         // type was read as a number, strings and time were labeled as bytes and uint64
 }
 
+// SchemeType is kind of value described by scheme node, it's written to json by its name
+type SchemeType = gotinyType
+
+// kinds of scheme nodes, tools reading schemes compare Scheme.Type with them
+const (
+	TypeIgnore     = typeIgnore
+	TypeStruct     = typeStruct
+	TypeSlice      = typeSlice
+	TypeArray      = typeArray
+	TypeMap        = typeMap
+	TypeBool       = typeBool
+	TypeInt        = typeInt
+	TypeInt8       = typeInt8
+	TypeInt16      = typeInt16
+	TypeInt32      = typeInt32
+	TypeInt64      = typeInt64
+	TypeUint       = typeUint
+	TypeUint8      = typeUint8
+	TypeUint16     = typeUint16
+	TypeUint32     = typeUint32
+	TypeUint64     = typeUint64
+	TypeFloat32    = typeFloat32
+	TypeFloat64    = typeFloat64
+	TypeBytes      = typeBytes
+	TypeTime       = typeTime
+	TypeInterface  = typeInterface
+	TypePointer    = typePointer
+	TypeComplex64  = typeComplex64
+	TypeComplex128 = typeComplex128
+	TypeCustom     = typeCustom
+	TypeString     = typeString
+)
+
 // SchemeNew creates new scheme node
 func SchemeNew(name string, encodeEngine encEng, decodeEngine decEng) Scheme {
 	return Scheme{
@@ -40,6 +84,121 @@ func (s *Scheme) AsJSON() string {
 	return string(res)
 }
 
+// impls returns names of types registered for interface node at the moment
+func (s *Scheme) impls() []string {
+	if s.Type == typeInterface && s.rt != nil {
+		return registeredImplementations(s.rt)
+	}
+	return s.Impls
+}
+
+// MarshalText returns name of type
+func (t gotinyType) MarshalText() ([]byte, error) {
+	if int(t) >= len(gotinyTypeNames) {
+		return nil, errors.New("gotiny: unknown scheme type " + t.String())
+	}
+	return []byte(gotinyTypeNames[t]), nil
+}
+
+// UnmarshalText sets type by its name
+func (t *gotinyType) UnmarshalText(text []byte) error {
+	for i, name := range gotinyTypeNames {
+		if name == string(text) {
+			*t = gotinyType(i)
+			return nil
+		}
+	}
+	return errors.New("gotiny: unknown scheme type " + strconv.Quote(string(text)))
+}
+
+// MarshalJSON writes type as its name
+func (t gotinyType) MarshalJSON() ([]byte, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.Quote(string(text))), nil
+}
+
+// UnmarshalJSON reads type either by name or by number used by previous versions of scheme
+func (t *gotinyType) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		name, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		return t.UnmarshalText([]byte(name))
+	}
+	n, err := strconv.ParseUint(string(data), 10, 8)
+	if err != nil {
+		return errors.New("gotiny: invalid scheme type " + string(data))
+	}
+	*t = gotinyType(n)
+	return nil
+}
+
+// sameType reports whether data described by s can be decoded with engines of o.
+// Schemes with numeric types may describe strings as bytes and time as uint64.
+// Arrays of different length are different types, length is unknown for schemes saved by previous versions
+func (s *Scheme) sameType(o *Scheme) bool {
+	if s.Packed != o.Packed {
+		return false
+	}
+	if s.Type == typeArray && o.Type == typeArray && s.Len != 0 && o.Len != 0 {
+		return s.Len == o.Len
+	}
+	return s.Type == o.Type || legacyType(s, o) || legacyType(o, s)
+}
+
+func legacyType(legacy, o *Scheme) bool {
+	return legacy.legacy && (legacy.Type == typeBytes && o.Type == typeString ||
+		legacy.Type == typeUint64 && o.Type == typeTime) ||
+		legacy.unknownKind() && o.isBasic()
+}
+
+// unknownKind reports whether node is leaf without type of scheme of previous version,
+// which wrote neither type of named basic types nor of empty structs, so size of its value isn't known
+func (s *Scheme) unknownKind() bool {
+	return s.legacy && s.Type == typeIgnore && len(s.Childs) == 0
+}
+
+// unknownKindError is returned when value of node with unknown kind has to be read without go type
+func (s *Scheme) unknownKindError() error {
+	return errors.New("gotiny: kind of value " + strconv.Quote(s.Name) + " isn't known in scheme of previous version")
+}
+
+// ambiguousBytes reports whether node of scheme of previous version is either string or []byte,
+// which were written with the same type. Caller tells which by setting GoType to "string" or "[]byte".
+func (s *Scheme) ambiguousBytes() bool {
+	return s.legacy && s.Type == typeBytes && s.GoType != "string" && s.GoType != "[]byte"
+}
+
+func (s *Scheme) ambiguousBytesError() error {
+	return errors.New("gotiny: value " + strconv.Quote(s.Name) + " is either string or []byte in scheme of previous version, set GoType of node to tell which")
+}
+
+// unknownLen reports whether node is array of scheme of previous version, which wrote no length of arrays.
+// Caller has to set Len of node.
+func (s *Scheme) unknownLen() bool {
+	return s.legacy && s.Type == typeArray && s.Len == 0
+}
+
+func (s *Scheme) unknownLenError() error {
+	return errors.New("gotiny: length of array " + strconv.Quote(s.Name) + " isn't known in scheme of previous version, set Len of node")
+}
+
+// isBasic reports whether node is of kind which previous versions wrote without type for named types
+func (s *Scheme) isBasic() bool {
+	switch s.Type {
+	case typeIgnore:
+		return len(s.Childs) == 0
+	case typeBool, typeInt, typeInt8, typeInt16, typeInt32, typeInt64, typeUint, typeUint8, typeUint16, typeUint32, typeUint64,
+		typeFloat32, typeFloat64, typeComplex64, typeComplex128, typeString:
+		return true
+	}
+	return false
+}
+
 func (s *Scheme) setStructEngines(place string) {
 	childs := s.Childs
 	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
@@ -47,6 +206,11 @@ func (s *Scheme) setStructEngines(place string) {
 			childs[i].encodeEngine(e, unsafe.Pointer(uintptr(p)+childs[i].offset))
 		}
 	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		for i := 0; i < len(childs); i++ {
+			childs[i].sizeEngine(e, unsafe.Pointer(uintptr(p)+childs[i].offset))
+		}
+	}
 	s.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
 		for i := 0; i < len(childs); i++ {
 			//fmt.Println("decode child", childs[i].Name, "offset", childs[i].offset)
@@ -55,10 +219,205 @@ func (s *Scheme) setStructEngines(place string) {
 	}
 }
 
+func (s *Scheme) setPointerEngines(rt reflect.Type) {
+	et, eNode := rt.Elem(), s.Childs[0]
+	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
+		isNotNil := !isNil(p)
+		e.encIsNotNil(isNotNil)
+		if isNotNil {
+			eNode.encodeEngine(e, *(*unsafe.Pointer)(p))
+		}
+	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		e.sizeBool()
+		if !isNil(p) {
+			eNode.sizeEngine(e, *(*unsafe.Pointer)(p))
+		}
+	}
+	s.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
+		if d.decIsNotNil() {
+			if isNil(p) {
+				*(*unsafe.Pointer)(p) = d.newValue(et)
+			}
+			eNode.decodeEngine(d, *(*unsafe.Pointer)(p))
+		} else if !isNil(p) {
+			*(*unsafe.Pointer)(p) = nil
+		}
+	}
+}
+
+func (s *Scheme) setArrayEngines(rt reflect.Type) {
+	l, size, eNode := rt.Len(), rt.Elem().Size(), s.Childs[0]
+	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
+		for i := 0; i < l; i++ {
+			eNode.encodeEngine(e, unsafe.Pointer(uintptr(p)+uintptr(i)*size))
+		}
+	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		for i := 0; i < l; i++ {
+			eNode.sizeEngine(e, unsafe.Pointer(uintptr(p)+uintptr(i)*size))
+		}
+	}
+	s.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
+		for i := 0; i < l; i++ {
+			eNode.decodeEngine(d, unsafe.Pointer(uintptr(p)+uintptr(i)*size))
+		}
+	}
+}
+
+func (s *Scheme) setSliceEngines(rt reflect.Type) {
+	size, eNode := rt.Elem().Size(), s.Childs[0]
+	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
+		isNotNil := !isNil(p)
+		e.encIsNotNil(isNotNil)
+		if isNotNil {
+			header := (*sliceHeader)(p)
+			e.encLength(header.len)
+			for i := 0; i < header.len; i++ {
+				eNode.encodeEngine(e, unsafe.Add(header.data, i*int(size)))
+			}
+		}
+	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		e.sizeBool()
+		if !isNil(p) {
+			header := (*sliceHeader)(p)
+			e.sizeLength(header.len)
+			for i := 0; i < header.len; i++ {
+				eNode.sizeEngine(e, unsafe.Add(header.data, i*int(size)))
+			}
+		}
+	}
+	s.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
+		header := (*sliceHeader)(p)
+		if d.decIsNotNil() {
+			l := d.decLength()
+			if isNil(p) || header.cap < l {
+				*header = sliceHeader{d.makeSlice(rt, l), l, l}
+			} else {
+				header.len = l
+			}
+			for i := 0; i < l; i++ {
+				eNode.decodeEngine(d, unsafe.Add(header.data, i*int(size)))
+			}
+		} else if !isNil(p) {
+			*header = sliceHeader{}
+		}
+	}
+}
+
+// mapScratch is storage for keys and values of entries of map being encoded or decoded,
+// it is taken from pool of map type, so nested maps of the same type have their own
+type mapScratch struct {
+	iter     reflect.MapIter
+	key, val reflect.Value
+}
+
+func newMapScratch(rt reflect.Type) *sync.Pool {
+	return &sync.Pool{New: func() interface{} {
+		return &mapScratch{key: reflect.New(rt.Key()).Elem(), val: reflect.New(rt.Elem()).Elem()}
+	}}
+}
+
+func (s *Scheme) setMapEngines(rt reflect.Type) {
+	kNode, eNode := s.Childs[0], s.Childs[1]
+	kZero, vZero := reflect.Zero(rt.Key()), reflect.Zero(rt.Elem())
+	scratches := newMapScratch(rt)
+	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
+		isNotNil := !isNil(p)
+		e.encIsNotNil(isNotNil)
+		if isNotNil {
+			v := reflect.NewAt(rt, p).Elem()
+			e.encLength(v.Len())
+			sc := scratches.Get().(*mapScratch)
+			sc.iter.Reset(v)
+			for sc.iter.Next() {
+				sc.key.SetIterKey(&sc.iter)
+				sc.val.SetIterValue(&sc.iter)
+				kNode.encodeEngine(e, unsafe.Pointer(sc.key.UnsafeAddr()))
+				eNode.encodeEngine(e, unsafe.Pointer(sc.val.UnsafeAddr()))
+			}
+			// scratch shouldn't keep map and its entries alive
+			sc.iter.Reset(reflect.Value{})
+			sc.key.Set(kZero)
+			sc.val.Set(vZero)
+			scratches.Put(sc)
+		}
+	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		e.sizeBool()
+		if !isNil(p) {
+			v := reflect.NewAt(rt, p).Elem()
+			e.sizeLength(v.Len())
+			sc := scratches.Get().(*mapScratch)
+			sc.iter.Reset(v)
+			for sc.iter.Next() {
+				sc.key.SetIterKey(&sc.iter)
+				sc.val.SetIterValue(&sc.iter)
+				kNode.sizeEngine(e, unsafe.Pointer(sc.key.UnsafeAddr()))
+				eNode.sizeEngine(e, unsafe.Pointer(sc.val.UnsafeAddr()))
+			}
+			sc.iter.Reset(reflect.Value{})
+			sc.key.Set(kZero)
+			sc.val.Set(vZero)
+			scratches.Put(sc)
+		}
+	}
+	s.decodeEngine = func(d *Decoder, p unsafe.Pointer) {
+		if d.decIsNotNil() {
+			l := d.decLength()
+			var v reflect.Value
+			if isNil(p) {
+				v = reflect.MakeMapWithSize(rt, l)
+				*(*unsafe.Pointer)(p) = unsafe.Pointer(v.Pointer())
+			} else {
+				v = reflect.NewAt(rt, p).Elem()
+			}
+			sc := scratches.Get().(*mapScratch)
+			for i := 0; i < l; i++ {
+				// engines reuse memory of not nil pointers, slices and maps, entries must not share it
+				sc.key.Set(kZero)
+				sc.val.Set(vZero)
+				kNode.decodeEngine(d, unsafe.Pointer(sc.key.UnsafeAddr()))
+				eNode.decodeEngine(d, unsafe.Pointer(sc.val.UnsafeAddr()))
+				v.SetMapIndex(sc.key, sc.val)
+			}
+			sc.key.Set(kZero)
+			sc.val.Set(vZero)
+			scratches.Put(sc)
+		} else if !isNil(p) {
+			*(*unsafe.Pointer)(p) = nil
+		}
+	}
+}
+
+// setContainerEngines sets engines of pointer, array, slice or map type rt using engines of s children,
+// returns false if s is not a container
+func (s *Scheme) setContainerEngines(rt reflect.Type) bool {
+	switch {
+	case s.Packed && (s.Type == typeSlice || s.Type == typeArray) && len(s.Childs) == 1:
+		s.setPackedEngines(rt)
+	case s.Type == typePointer && len(s.Childs) == 1:
+		s.setPointerEngines(rt)
+	case s.Type == typeArray && len(s.Childs) == 1:
+		s.setArrayEngines(rt)
+	case s.Type == typeSlice && len(s.Childs) == 1:
+		s.setSliceEngines(rt)
+	case s.Type == typeMap && len(s.Childs) == 2:
+		s.setMapEngines(rt)
+	default:
+		return false
+	}
+	return true
+}
+
 // should add engines wich skips on decode and writes empty value on encode
 func (s *Scheme) setEmptyEngines() {
 	if s.Type == typeStruct {
 		s.setStructEngines("via empty engines")
+	} else if s.unknownKind() {
+		err := s.unknownKindError()
+		s.decodeEngine = func(d *Decoder, p unsafe.Pointer) { panic(err) }
 	} else {
 		s.decodeEngine = type2Empty[s.Type]
 	}
@@ -66,6 +425,9 @@ func (s *Scheme) setEmptyEngines() {
 	s.encodeEngine = func(e *Encoder, p unsafe.Pointer) {
 		panic("encodeing empty type from dinamic scheme not supported yet")
 	}
+	s.sizeEngine = func(e *Encoder, p unsafe.Pointer) {
+		panic("encodeing empty type from dinamic scheme not supported yet")
+	}
 }
 
 // find scheme node child from inside current scheme (object representation)
@@ -78,28 +440,43 @@ func (s *Scheme) find(childToFind *Scheme) *Scheme {
 	return nil
 }
 
-// prepare sets engines using main object scheme
-func (s *Scheme) fillEngines(coder *Coder, originalScheme *Scheme) {
+// prepare sets engines using main object scheme,
+// visited protects from endless walking over schemes of recursive types
+func (s *Scheme) fillEngines(coder *Coder, originalScheme *Scheme, visited map[*Scheme]bool) {
+	if visited[s] {
+		return
+	}
+	visited[s] = true
 	if originalScheme == nil {
 		for _, child := range s.Childs {
-			child.fillEngines(coder, nil)
+			child.fillEngines(coder, nil, visited)
 		}
 		s.setEmptyEngines()
 		return
 	}
 
-	for _, child := range s.Childs {
-		originalChild := originalScheme.find(child)
-		child.fillEngines(coder, originalChild)
+	sameType := s.sameType(originalScheme)
+	for i, child := range s.Childs {
+		// struct fields are matched by name, elements of containers by position
+		var originalChild *Scheme
+		if sameType && s.Type == typeStruct {
+			originalChild = originalScheme.find(child)
+		} else if sameType && i < len(originalScheme.Childs) {
+			originalChild = originalScheme.Childs[i]
+		}
+		child.fillEngines(coder, originalChild, visited)
 	}
 
-	if s.Type != originalScheme.Type {
+	if !sameType {
 		s.setEmptyEngines()
 	} else if s.Type == typeStruct {
 		s.setStructEngines("via prepare")
+	} else if originalScheme.rt != nil && s.setContainerEngines(originalScheme.rt) {
+		// elements are migrated by their own engines
 	} else {
 		s.encodeEngine = originalScheme.encodeEngine
 		s.decodeEngine = originalScheme.decodeEngine
+		s.sizeEngine = originalScheme.sizeEngine
 	}
 	s.offset = originalScheme.offset
 }
@@ -0,0 +1,315 @@
+package gotiny_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type (
+	schemeT1 struct {
+		I   uint32
+		Str string
+		T   time.Time
+	}
+	schemeT2 struct {
+		Str string
+		T   time.Time
+		I   uint32
+	}
+)
+
+func TestSchemeJSONTypes(t *testing.T) {
+	type node struct {
+		Arr [2]int8
+		M   map[tint]string
+		R   interface{ Close() error }
+	}
+	js := gotiny.New(node{}, time.Time{}).GetScheme().AsJSON()
+	for _, exp := range []string{
+		`"type":"struct","goType":"github.com/niubaoshu/gotiny_test.node"`,
+		`"name":"Arr","type":"array","goType":"[2]int8","len":2`,
+		`"name":"key","type":"int","goType":"github.com/niubaoshu/gotiny_test.tint"`,
+		`"name":"value","type":"string","goType":"string"`,
+		`"type":"time","goType":"time.Time"`,
+	} {
+		if !strings.Contains(js, exp) {
+			t.Errorf("%s doesn't contain %s", js, exp)
+		}
+	}
+
+	scheme, err := gotiny.SchemeFromJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scheme.AsJSON(); got != js {
+		t.Errorf("scheme changed after reading from json:\n%s\n%s", js, got)
+	}
+
+	if _, err := gotiny.SchemeFromJSON(`{"type":"unknown"}`); err == nil {
+		t.Error("expected error for unknown type")
+	}
+}
+
+func TestSchemeLegacyJSON(t *testing.T) {
+	// scheme of schemeT1 written before types got names
+	scheme, err := gotiny.SchemeFromJSON(`{"childs":[{"type":1,"childs":[{"name":"I","type":14},{"name":"Str","type":18},{"name":"T","type":15}]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := schemeT1{I: 32, Str: "wow", T: time.Unix(10, 20)}
+	encoded := gotiny.New(schemeT1{}).Encode(&src)
+
+	coder := gotiny.New(schemeT2{})
+	coder.SetScheme(scheme)
+	var got schemeT2
+	coder.Decode(encoded, &got)
+	if got.I != src.I || got.Str != src.Str || !got.T.Equal(src.T) {
+		t.Errorf("expected %+v, got %+v", src, got)
+	}
+}
+
+type (
+	legacyStatus int32
+	legacyNamedT struct {
+		A legacyStatus
+		B string
+		C int
+	}
+)
+
+// TestSchemeLegacyNamedBasic reads scheme and data written by version which wrote no type of named basic types
+func TestSchemeLegacyNamedBasic(t *testing.T) {
+	legacyJSON := `{"childs":[{"type":1,"childs":[{"name":"A"},{"name":"B","type":18},{"name":"C","type":6}]}]}`
+	data, _ := hex.DecodeString("0a0268690e")
+	scheme, err := gotiny.SchemeFromJSON(legacyJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coder := gotiny.New(legacyNamedT{})
+	coder.SetScheme(scheme)
+	var got legacyNamedT
+	coder.Decode(data, &got)
+	if exp := (legacyNamedT{A: 5, B: "hi", C: 7}); got != exp {
+		t.Errorf("expected %+v, got %+v", exp, got)
+	}
+	if report := gotiny.CheckCompatibility(scheme, gotiny.New(legacyNamedT{}).GetScheme()); len(report.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", report.Changes)
+	}
+
+	// size of value of field without type isn't known, it can't be skipped or decoded without go type
+	type withoutA struct {
+		B string
+		C int
+	}
+	if report := gotiny.CheckCompatibility(scheme, gotiny.New(withoutA{}).GetScheme()); !report.Breaking() {
+		t.Errorf("expected removed field of unknown kind to be breaking, got %v", report.Changes)
+	}
+	if _, err := gotiny.DecodeDynamic(scheme, data); err == nil || !strings.Contains(err.Error(), `kind of value "A" isn't known`) {
+		t.Errorf("expected error for field of unknown kind, got %v", err)
+	}
+}
+
+func TestSchemeBinary(t *testing.T) {
+	type pair struct {
+		A, B schemeT1
+	}
+	scheme := gotiny.New(pair{}, []schemeT1{}, map[string]schemeT2{}).GetScheme()
+	data, err := scheme.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if js := scheme.AsJSON(); len(data)*2 > len(js) {
+		t.Errorf("binary scheme is too large: %d bytes, json %d bytes", len(data), len(js))
+	}
+	var got gotiny.Scheme
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if exp, js := scheme.AsJSON(), got.AsJSON(); exp != js {
+		t.Errorf("expected scheme\n%s\ngot\n%s", exp, js)
+	}
+
+	if err := got.UnmarshalBinary(data[:len(data)/2]); err == nil {
+		t.Error("expected error for truncated data")
+	}
+}
+
+// TestSchemeBinaryV1 decodes scheme of struct{ A []float64; M map[string][3]int8 } written by version 1 without packed flags
+func TestSchemeBinaryV1(t *testing.T) {
+	data, _ := hex.DecodeString("0149080000000001010001066d61696e2e5400020204014102095b5d666c6f6174363400920103001107666c6f6174363400" +
+		"00014d04126d61705b737472696e675d5b335d696e743800020506036b65791906737472696e670024000576616c756503075b335d696e7438060107000704696e7438000100")
+	var got gotiny.Scheme
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"childs":[{"type":"struct","goType":"main.T","childs":[{"name":"A","type":"slice","goType":"[]float64","childs":[{"type":"float64","goType":"float64"}]},` +
+		`{"name":"M","type":"map","goType":"map[string][3]int8","childs":[{"name":"key","type":"string","goType":"string"},` +
+		`{"name":"value","type":"array","goType":"[3]int8","len":3,"childs":[{"type":"int8","goType":"int8"}]}]}]}]}`
+	if js := got.AsJSON(); js != exp {
+		t.Errorf("expected scheme\n%s\ngot\n%s", exp, js)
+	}
+}
+
+func TestSchemeBinaryRecursive(t *testing.T) {
+	scheme := gotiny.New(compatTree{}, cirStruct{}).GetScheme()
+	data, err := scheme.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got gotiny.Scheme
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if report := gotiny.CheckCompatibility(&got, scheme); len(report.Changes) != 0 {
+		t.Errorf("unexpected changes:\n%s", report)
+	}
+}
+
+type compatTree2 struct {
+	Children []*compatTree2
+	Label    string
+	Value    int
+}
+
+func TestSchemeJSONRecursive(t *testing.T) {
+	scheme := gotiny.New(compatTree{}, vcir, cirMap{}).GetScheme()
+	js := scheme.AsJSON()
+	for _, exp := range []string{
+		`"id":"github.com/niubaoshu/gotiny_test.compatTree"`,
+		`{"ref":"github.com/niubaoshu/gotiny_test.compatTree"}`,
+		`{"name":"value","ref":"github.com/niubaoshu/gotiny_test.cirMap"}`,
+	} {
+		if !strings.Contains(js, exp) {
+			t.Errorf("%s doesn't contain %s", js, exp)
+		}
+	}
+
+	read, err := gotiny.SchemeFromJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := read.AsJSON(); got != js {
+		t.Errorf("scheme changed after reading from json:\n%s\n%s", js, got)
+	}
+	if report := gotiny.CheckCompatibility(read, scheme); len(report.Changes) != 0 {
+		t.Errorf("unexpected changes:\n%s", report)
+	}
+
+	if _, err := gotiny.SchemeFromJSON(`{"type":"pointer","childs":[{"ref":"T"}]}`); err == nil {
+		t.Error("expected error for unknown reference")
+	}
+}
+
+func TestSchemeRecursiveMigration(t *testing.T) {
+	writer := gotiny.New(compatTree{})
+	src := compatTree{Value: 1, Children: []*compatTree{{Value: 2, Children: []*compatTree{{Value: 3}}}, nil}}
+	encoded := writer.Encode(&src)
+	exp := compatTree2{Value: 1, Children: []*compatTree2{{Value: 2, Children: []*compatTree2{{Value: 3}}}, nil}}
+
+	fromJSON, err := gotiny.SchemeFromJSON(writer.GetScheme().AsJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := writer.GetScheme().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary gotiny.Scheme
+	if err := fromBinary.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scheme := range []*gotiny.Scheme{fromJSON, &fromBinary} {
+		reader := gotiny.New(compatTree2{})
+		reader.SetScheme(scheme)
+		var got compatTree2
+		reader.Decode(encoded, &got)
+		if !c.DeepEqual(exp, got) {
+			t.Errorf("expected %+v, got %+v", exp, got)
+		}
+	}
+}
+
+func TestSchemeGoSource(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command is not available")
+	}
+	scheme := gotiny.New(compatV1{}, compatTree{}, schemeT1{}, map[tint][2]*int8{}, cirMap{}).GetScheme()
+	src, err := scheme.GoSource("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// generated types are compiled in temporary package of this module and print their scheme
+	dir, err := ioutil.TempDir(".", "_gosource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	main := `package main
+
+import (
+	"os"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+func main() {
+	os.Stdout.WriteString(gotiny.NewWithPtr(Types...).GetScheme().AsJSON())
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "types.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("go", "run", "./"+dir).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s\n%s", err, out, src)
+	}
+
+	exp := strings.Replace(scheme.AsJSON(), "github.com/niubaoshu/gotiny_test.", "main.", -1)
+	if got := string(out); got != exp {
+		t.Errorf("scheme of generated types differs:\n%s\n%s\n%s", exp, got, src)
+	}
+}
+
+type schemaList struct {
+	ID   int8
+	Raw  []byte
+	Tags map[uint16]bool
+	Next *schemaList
+}
+
+func TestSchemeJSONSchema(t *testing.T) {
+	got, err := gotiny.New(schemaList{}).GetScheme().Childs[0].JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"$schema":"https://json-schema.org/draft/2020-12/schema","$ref":"#/$defs/schemaList","$defs":{"schemaList":{` +
+		`"title":"github.com/niubaoshu/gotiny_test.schemaList","type":"object","properties":{` +
+		`"ID":{"type":"integer","minimum":-128,"maximum":127},` +
+		`"Next":{"anyOf":[{"$ref":"#/$defs/schemaList"},{"type":"null"}]},` +
+		`"Raw":{"type":["string","null"],"contentEncoding":"base64"},` +
+		`"Tags":{"type":["array","null"],"items":{"type":"array","prefixItems":[` +
+		`{"type":"integer","minimum":0,"maximum":65535},{"type":"boolean"}],"items":false,"minItems":2}}},` +
+		`"required":["ID","Raw","Tags","Next"],"additionalProperties":false}}}`
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, got); err != nil {
+		t.Fatal(err)
+	}
+	if compact.String() != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, compact.String())
+	}
+}
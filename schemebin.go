@@ -0,0 +1,195 @@
+package gotiny
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	schemeBinaryVersion  = 2
+	maxSchemeBinaryNodes = 1 << 20 // protects from expanding of malicious data
+)
+
+// schemeTable is compact representation of scheme graph,
+// equal subtrees and recursive types refer to the same record, first record is the root
+type schemeTable struct {
+	Records []schemeRecord
+}
+
+type schemeRecord struct {
+	Name   string
+	Type   gotinyType
+	GoType string
+	Len    int
+	Impls  []string
+	Legacy bool
+	Packed bool
+	Childs []uint32 // indexes of records
+}
+
+// schemeTableV1 is table of version 1, which had no packed nodes
+type schemeTableV1 struct {
+	Records []struct {
+		Name   string
+		Type   gotinyType
+		GoType string
+		Len    int
+		Impls  []string
+		Legacy bool
+		Childs []uint32
+	}
+}
+
+var (
+	schemeTableCoder   = New(schemeTable{})
+	schemeTableV1Coder = New(schemeTableV1{})
+)
+
+// MarshalBinary encodes scheme with gotiny itself,
+// equal subtrees are written once, so result is much smaller than json
+func (s *Scheme) MarshalBinary() ([]byte, error) {
+	table := s.table()
+	return append([]byte{schemeBinaryVersion}, schemeTableCoder.Encode(&table)...), nil
+}
+
+// UnmarshalBinary decodes scheme encoded by MarshalBinary
+func (s *Scheme) UnmarshalBinary(data []byte) (err error) {
+	if len(data) == 0 || data[0] != schemeBinaryVersion && data[0] != 1 {
+		return errors.New("gotiny: unsupported scheme binary format")
+	}
+	var table schemeTable
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("gotiny: corrupted scheme binary data: %v", r)
+		}
+	}()
+	if data[0] == 1 {
+		var v1 schemeTableV1
+		schemeTableV1Coder.Decode(data[1:], &v1)
+		table.Records = make([]schemeRecord, len(v1.Records))
+		for i, r := range v1.Records {
+			table.Records[i] = schemeRecord{Name: r.Name, Type: r.Type, GoType: r.GoType, Len: r.Len, Impls: r.Impls, Legacy: r.Legacy, Childs: r.Childs}
+		}
+	} else {
+		schemeTableCoder.Decode(data[1:], &table)
+	}
+	if len(table.Records) == 0 {
+		return errors.New("gotiny: empty scheme binary data")
+	}
+	for _, record := range table.Records {
+		if int(record.Type) >= len(gotinyTypeNames) {
+			return errors.New("gotiny: unknown scheme type " + record.Type.String())
+		}
+		for _, child := range record.Childs {
+			if int(child) >= len(table.Records) {
+				return errors.New("gotiny: scheme record " + strconv.Itoa(int(child)) + " doesn't exist")
+			}
+		}
+	}
+	*s = Scheme{}
+	nodes := 0
+	return table.expand(s, 0, map[uint32]*Scheme{}, &nodes)
+}
+
+// expand fills node from record, records referenced from their own subtree become cycles
+// same as in schemes built from recursive types, other references are copied
+func (t *schemeTable) expand(node *Scheme, index uint32, path map[uint32]*Scheme, nodes *int) error {
+	if *nodes++; *nodes > maxSchemeBinaryNodes {
+		return errors.New("gotiny: scheme binary data is too large")
+	}
+	record := &t.Records[index]
+	node.Name, node.Type, node.GoType, node.Len, node.legacy = record.Name, record.Type, record.GoType, record.Len, record.Legacy
+	node.Packed = record.Packed
+	node.Impls = record.Impls
+	if len(record.Childs) == 0 {
+		return nil
+	}
+	path[index] = node
+	node.Childs = make([]*Scheme, len(record.Childs))
+	for i, child := range record.Childs {
+		if ancestor, ok := path[child]; ok {
+			node.Childs[i] = ancestor
+			continue
+		}
+		node.Childs[i] = &Scheme{}
+		if err := t.expand(node.Childs[i], child, path, nodes); err != nil {
+			return err
+		}
+	}
+	delete(path, index)
+	return nil
+}
+
+// table collects nodes of scheme graph and merges equal ones by partition refinement,
+// two nodes are equal if they have the same attributes and their children are pairwise equal
+func (s *Scheme) table() schemeTable {
+	ids := map[*Scheme]int{}
+	var nodes []*Scheme
+	var collect func(node *Scheme)
+	collect = func(node *Scheme) {
+		if _, ok := ids[node]; ok {
+			return
+		}
+		ids[node] = len(nodes)
+		nodes = append(nodes, node)
+		for _, child := range node.Childs {
+			collect(child)
+		}
+	}
+	collect(s)
+
+	records := make([]schemeRecord, len(nodes))
+	class := make([]int, len(nodes))
+	keys := map[string]int{}
+	for i, node := range nodes {
+		records[i] = schemeRecord{Name: node.Name, Type: node.Type, GoType: node.GoType, Len: node.Len, Impls: node.impls(), Legacy: node.legacy, Packed: node.Packed}
+		r := &records[i]
+		key := fmt.Sprintf("%q %d %q %d %q %t %t %d", r.Name, r.Type, r.GoType, r.Len, strings.Join(r.Impls, ","), r.Legacy, r.Packed, len(node.Childs))
+		class[i] = classOf(keys, key)
+	}
+	for classes := len(keys); ; {
+		keys = map[string]int{}
+		next := make([]int, len(nodes))
+		for i, node := range nodes {
+			key := strconv.Itoa(class[i])
+			for _, child := range node.Childs {
+				key += " " + strconv.Itoa(class[ids[child]])
+			}
+			next[i] = classOf(keys, key)
+		}
+		class = next
+		if len(keys) == classes {
+			break
+		}
+		classes = len(keys)
+	}
+
+	// classes are numbered in order of first appearance, so root is the first record
+	table := schemeTable{Records: make([]schemeRecord, len(keys))}
+	written := make([]bool, len(keys))
+	for i, node := range nodes {
+		c := class[i]
+		if written[c] {
+			continue
+		}
+		written[c] = true
+		record := records[i]
+		record.Childs = make([]uint32, len(node.Childs))
+		for j, child := range node.Childs {
+			record.Childs[j] = uint32(class[ids[child]])
+		}
+		table.Records[c] = record
+	}
+	return table
+}
+
+func classOf(keys map[string]int, key string) int {
+	c, ok := keys[key]
+	if !ok {
+		c = len(keys)
+		keys[key] = c
+	}
+	return c
+}
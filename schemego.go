@@ -0,0 +1,259 @@
+package gotiny
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GoSource returns source of package pkg with go types having scheme s,
+// so data can be decoded without types it was encoded from.
+// Structs and named types get declarations named after original types,
+// variable Types lists nil pointers to types of top level values,
+// gotiny.NewWithPtr(Types...) creates coder with the same scheme.
+// Types with custom encoding are declared as structs keeping encoded bytes,
+// it is only correct for types implementing encoding.BinaryMarshaler or gob.GobEncoder.
+// Members of idl unions are registered with their names in init function.
+func (s *Scheme) GoSource(pkg string) ([]byte, error) {
+	w := goSourceWriter{
+		names:      map[string]string{},
+		used:       map[string]bool{"Types": true, "time": true, "reflect": true, "gotiny": true},
+		path:       map[*Scheme]bool{},
+		registered: map[string]bool{},
+	}
+	values := []*Scheme{s}
+	if s.isRoot() {
+		values = s.Childs
+	}
+	var types []string
+	for i, value := range values {
+		typ, err := w.typeExpr(value, "Value"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, "(*"+typ+")(nil)")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated from gotiny scheme. DO NOT EDIT.\n\npackage " + pkg + "\n")
+	var imports []string
+	if len(w.registers) > 0 {
+		imports = append(imports, "\"reflect\"")
+	}
+	if w.time {
+		imports = append(imports, "\"time\"")
+	}
+	if len(w.registers) > 0 {
+		imports = append(imports, "\n\"github.com/niubaoshu/gotiny\"")
+	}
+	if len(imports) > 0 {
+		buf.WriteString("\nimport (\n" + strings.Join(imports, "\n") + "\n)\n")
+	}
+	buf.WriteString("\n// Types lists nil pointers to types of scheme values, gotiny.NewWithPtr(Types...) creates coder of the scheme\n")
+	buf.WriteString("var Types = []interface{}{" + strings.Join(types, ", ") + "}\n")
+	if len(w.registers) > 0 {
+		buf.WriteString("\nfunc init() {\n" + strings.Join(w.registers, "") + "}\n")
+	}
+	for _, decl := range w.decls {
+		buf.WriteString(decl)
+	}
+	return format.Source(buf.Bytes())
+}
+
+type goSourceWriter struct {
+	names map[string]string // declared type names by type key
+	used  map[string]bool
+	path  map[*Scheme]bool // enclosing nodes written as literals
+	decls []string
+	time  bool
+
+	registered map[string]bool // go types of registered union members
+	registers  []string
+}
+
+// key identifies type of node, nodes without go type are identified by address
+func (w *goSourceWriter) key(s *Scheme) string {
+	if s.GoType != "" {
+		return s.GoType
+	}
+	return fmt.Sprintf("%p", s)
+}
+
+// typeExpr returns go type of node, declaring it if needed, hint is used as name of unnamed structs
+func (w *goSourceWriter) typeExpr(s *Scheme, hint string) (string, error) {
+	name := namedGoType(s.GoType)
+	if name == "" && s.Type != typeStruct && s.Type != typeCustom {
+		return w.literal(s, hint)
+	}
+	key := w.key(s)
+	if declared, ok := w.names[key]; ok {
+		return declared, nil
+	}
+	if name == "" {
+		name = hint
+	}
+	name = w.newName(name)
+	w.names[key] = name
+	i := len(w.decls)
+	w.decls = append(w.decls, "") // declaration is reserved, so enclosing types go first
+
+	comment := ""
+	if s.GoType != "" {
+		comment = "// " + name + " is " + s.GoType + "\n"
+	}
+	switch s.Type {
+	case typeStruct:
+		var fields strings.Builder
+		for j, child := range s.Childs {
+			fieldName := child.Name
+			if fieldName == "" {
+				fieldName = "Field" + strconv.Itoa(j)
+			}
+			typ, err := w.typeExpr(child, name+exported(fieldName))
+			if err != nil {
+				return "", err
+			}
+			if child.Packed {
+				typ += " `gotiny:\"packed\"`"
+			}
+			fields.WriteString(fieldName + " " + typ + "\n")
+		}
+		w.decls[i] = "\n" + comment + "type " + name + " struct {\n" + fields.String() + "}\n"
+	case typeCustom:
+		w.decls[i] = "\n" + comment + "// its encoding is custom, encoded bytes are kept as is\ntype " + name + " struct {\nData []byte\n}\n\n" +
+			"func (v *" + name + ") MarshalBinary() ([]byte, error) { return v.Data, nil }\n\n" +
+			"func (v *" + name + ") UnmarshalBinary(data []byte) error {\nv.Data = append(v.Data[:0], data...)\nreturn nil\n}\n"
+	default:
+		typ, err := w.literal(s, name)
+		if err != nil {
+			return "", err
+		}
+		w.decls[i] = "\n" + comment + "type " + name + " " + typ + "\n"
+	}
+	return name, nil
+}
+
+// literal returns go type of node which isn't declared
+func (w *goSourceWriter) literal(s *Scheme, hint string) (string, error) {
+	switch s.Type {
+	case typeBool, typeInt, typeInt8, typeInt16, typeInt32, typeInt64, typeUint, typeUint8, typeUint16, typeUint32,
+		typeFloat32, typeFloat64, typeComplex64, typeComplex128, typeString:
+		return s.Type.String(), nil
+	case typeUint64:
+		if s.GoType == "uintptr" {
+			return s.GoType, nil
+		}
+		return s.Type.String(), nil
+	case typeBytes:
+		if s.ambiguousBytes() {
+			return "", s.ambiguousBytesError()
+		}
+		if s.GoType == "string" {
+			return "string", nil
+		}
+		return "[]byte", nil
+	case typeTime:
+		w.time = true
+		return "time.Time", nil
+	case typeInterface:
+		// types of values of idl unions are registered with their names
+		for _, child := range s.Childs {
+			typ, err := w.typeExpr(child, hint+"Impl")
+			if err != nil {
+				return "", err
+			}
+			if !w.registered[child.GoType] {
+				w.registered[child.GoType] = true
+				w.registers = append(w.registers, "gotiny.RegisterName("+strconv.Quote(child.GoType)+", reflect.TypeOf((*"+typ+")(nil)).Elem())\n")
+			}
+		}
+		return "interface{}", nil
+	case typeIgnore:
+		if s.unknownKind() {
+			return "", s.unknownKindError()
+		}
+		return "struct{}", nil
+	case typeStruct, typeCustom:
+		return w.typeExpr(s, hint)
+	}
+
+	if s.unknownLen() {
+		return "", s.unknownLenError()
+	}
+	if w.path[s] {
+		return "", errors.New("gotiny: scheme node " + strconv.Quote(s.Name) + " is recursive, but has no go type name")
+	}
+	w.path[s] = true
+	defer delete(w.path, s)
+	var elems []string
+	for j, child := range s.Childs {
+		childHint := hint + "Elem"
+		if s.Type == typeMap {
+			childHint = hint + [...]string{"Key", "Value"}[j%2]
+		}
+		typ, err := w.typeExpr(child, childHint)
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, typ)
+	}
+	switch {
+	case s.Type == typePointer && len(elems) == 1:
+		return "*" + elems[0], nil
+	case s.Type == typeSlice && len(elems) == 1:
+		return "[]" + elems[0], nil
+	case s.Type == typeArray && len(elems) == 1:
+		return "[" + strconv.Itoa(s.Len) + "]" + elems[0], nil
+	case s.Type == typeMap && len(elems) == 2:
+		return "map[" + elems[0] + "]" + elems[1], nil
+	}
+	return "", errors.New("gotiny: scheme node " + strconv.Quote(s.Name) + " of type " + s.Type.String() + " can't be expressed in go")
+}
+
+func (w *goSourceWriter) newName(name string) string {
+	n := name
+	for i := 2; w.used[n] || isPredeclaredName(n); i++ {
+		n = name + strconv.Itoa(i)
+	}
+	w.used[n] = true
+	return n
+}
+
+// namedGoType returns name of named type from its full name, or empty string for unnamed and predeclared types
+func namedGoType(goType string) string {
+	if goType == "" || goType == "time.Time" || strings.ContainsAny(goType[:1], "[*") {
+		return ""
+	}
+	for _, prefix := range []string{"map[", "struct {", "interface {", "func(", "chan "} {
+		if strings.HasPrefix(goType, prefix) {
+			return ""
+		}
+	}
+	if i := strings.IndexByte(goType, '['); i >= 0 { // instantiated generic type
+		goType = goType[:i]
+	}
+	i := strings.LastIndexByte(goType, '.')
+	if i < 0 {
+		return ""
+	}
+	return goType[i+1:]
+}
+
+func exported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func isPredeclaredName(name string) bool {
+	switch name {
+	case "bool", "byte", "complex64", "complex128", "error", "float32", "float64", "int", "int8", "int16", "int32", "int64",
+		"rune", "string", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "any", "comparable":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package gotiny
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// jsonScheme is json form of scheme node.
+// Scheme graph of recursive type has cycles, in json they are replaced by references:
+// node of type which is already being described by one of enclosing nodes gets "ref"
+// with id of that node, enclosing node gets "id" equal to its go type name.
+type jsonScheme struct {
+	Name   string          `json:"name,omitempty"`
+	Type   json.RawMessage `json:"type,omitempty"`
+	GoType string          `json:"goType,omitempty"`
+	Len    int             `json:"len,omitempty"`
+	Packed bool            `json:"packed,omitempty"`
+	Impls  []string        `json:"impls,omitempty"`
+	ID     string          `json:"id,omitempty"`
+	Ref    string          `json:"ref,omitempty"`
+	Childs []*jsonScheme   `json:"childs,omitempty"`
+}
+
+// MarshalJSON writes scheme, interface nodes list names of currently registered types implementing them
+func (s *Scheme) MarshalJSON() ([]byte, error) {
+	w := jsonSchemeWriter{path: map[string]*jsonScheme{}}
+	node, err := w.node(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+type jsonSchemeWriter struct {
+	path map[string]*jsonScheme // enclosing nodes by their key
+	ids  int
+}
+
+// key identifies type of node, nodes without go type are identified by address
+func (w *jsonSchemeWriter) key(s *Scheme) string {
+	if s.GoType != "" {
+		return s.GoType
+	}
+	return fmt.Sprintf("%p", s)
+}
+
+func (w *jsonSchemeWriter) node(s *Scheme) (*jsonScheme, error) {
+	key := w.key(s)
+	if def, ok := w.path[key]; ok {
+		if def.ID == "" {
+			if def.ID = s.GoType; def.ID == "" {
+				w.ids++
+				def.ID = "#" + strconv.Itoa(w.ids)
+			}
+		}
+		return &jsonScheme{Name: s.Name, Ref: def.ID}, nil
+	}
+
+	node := &jsonScheme{Name: s.Name, GoType: s.GoType, Len: s.Len, Packed: s.Packed, Impls: s.impls()}
+	if s.Type != typeIgnore {
+		typ, err := s.Type.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		node.Type = typ
+	}
+	w.path[key] = node
+	for _, child := range s.Childs {
+		jsonChild, err := w.node(child)
+		if err != nil {
+			return nil, err
+		}
+		node.Childs = append(node.Childs, jsonChild)
+	}
+	delete(w.path, key)
+	return node, nil
+}
+
+// UnmarshalJSON reads scheme, type may be either a name or a number used by previous versions
+func (s *Scheme) UnmarshalJSON(data []byte) error {
+	var node jsonScheme
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	r := jsonSchemeReader{path: map[string]*Scheme{}, legacy: node.legacy()}
+	*s = Scheme{}
+	if err := r.node(s, &node); err != nil {
+		return err
+	}
+	// nodes referring to enclosing node under different name are copied when it is complete
+	for _, copied := range r.copies {
+		name := copied.node.Name
+		*copied.node = *copied.def
+		copied.node.Name = name
+	}
+	return nil
+}
+
+type jsonSchemeReader struct {
+	path   map[string]*Scheme // enclosing nodes by their id
+	copies []struct{ node, def *Scheme }
+	legacy bool // scheme was written by previous version, all its nodes are legacy
+}
+
+// legacy reports whether any node of tree has numeric type, previous versions wrote types as numbers
+func (node *jsonScheme) legacy() bool {
+	if len(node.Type) != 0 && node.Type[0] != '"' {
+		return true
+	}
+	for _, child := range node.Childs {
+		if child.legacy() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *jsonSchemeReader) node(s *Scheme, node *jsonScheme) error {
+	s.Name, s.GoType, s.Len, s.Packed, s.Impls = node.Name, node.GoType, node.Len, node.Packed, node.Impls
+	s.legacy = r.legacy
+	if len(node.Type) != 0 {
+		if err := s.Type.UnmarshalJSON(node.Type); err != nil {
+			return err
+		}
+	}
+	if node.ID != "" {
+		if prev, ok := r.path[node.ID]; ok {
+			defer func() { r.path[node.ID] = prev }()
+		} else {
+			defer delete(r.path, node.ID)
+		}
+		r.path[node.ID] = s
+	}
+
+	s.Childs = make([]*Scheme, 0, len(node.Childs))
+	for _, jsonChild := range node.Childs {
+		if jsonChild.Ref != "" {
+			def, ok := r.path[jsonChild.Ref]
+			if !ok {
+				return errors.New("gotiny: scheme reference " + strconv.Quote(jsonChild.Ref) + " doesn't refer to enclosing node")
+			}
+			if def.Name == jsonChild.Name {
+				s.Childs = append(s.Childs, def)
+			} else {
+				child := &Scheme{Name: jsonChild.Name}
+				r.copies = append(r.copies, struct{ node, def *Scheme }{child, def})
+				s.Childs = append(s.Childs, child)
+			}
+			continue
+		}
+		child := &Scheme{}
+		if err := r.node(child, jsonChild); err != nil {
+			return err
+		}
+		s.Childs = append(s.Childs, child)
+	}
+	if len(s.Childs) == 0 {
+		s.Childs = nil
+	}
+	return nil
+}
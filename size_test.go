@@ -0,0 +1,176 @@
+package gotiny_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+func TestSize(t *testing.T) {
+	if exp, got := len(gotiny.Marshal(srci...)), gotiny.NewWithPtr(srci...).Size(srci...); exp != got {
+		t.Errorf("expected size %d of all values, got %d", exp, got)
+	}
+	for i := range srci {
+		if exp, got := len(gotiny.Marshal(srci[i])), gotiny.NewWithPtr(srci[i]).Size(srci[i]); exp != got {
+			t.Errorf("%d %T: expected size %d, got %d", i, srci[i], exp, got)
+		}
+	}
+}
+
+func TestSizeVarints(t *testing.T) {
+	type ints struct {
+		U64 uint64
+		I64 int64
+		U32 uint32
+		I32 int32
+		U16 uint16
+		I16 int16
+		I   int
+		F64 float64
+		F32 float32
+		C   complex128
+		S   string
+		B   [9]bool
+	}
+	coder := gotiny.New(ints{})
+	for k := uint(0); k < 64; k++ {
+		for _, u := range []uint64{1<<k - 2, 1<<k - 1, 1 << k} {
+			v := ints{
+				U64: u, I64: -int64(u), U32: uint32(u), I32: int32(u), U16: uint16(u), I16: -int16(u), I: int(u),
+				F64: float64(u), F32: float32(u), C: complex(float64(u), -float64(u)), S: string(make([]byte, u%300)),
+			}
+			if exp, got := len(coder.Encode(&v)), coder.Size(&v); exp != got {
+				t.Errorf("%d: expected size %d, got %d", u, exp, got)
+			}
+		}
+	}
+	v := ints{U64: math.MaxUint64, I64: math.MinInt64, F64: math.NaN(), B: [9]bool{8: true}}
+	if exp, got := len(coder.Encode(&v)), coder.Size(&v); exp != got {
+		t.Errorf("expected size %d, got %d", exp, got)
+	}
+}
+
+func TestSizeScheme(t *testing.T) {
+	coder := gotiny.New(packedT{}, map[string]interface{}{})
+	values := []interface{}{&packedV, &map[string]interface{}{"a": tint(1), "b": nil, "c": []string{"x"}}}
+	exp := len(coder.Encode(values...))
+	if got := coder.Size(values...); exp != got {
+		t.Errorf("expected size %d, got %d", exp, got)
+	}
+
+	// engines of scheme read from json are engines of types
+	scheme, err := gotiny.SchemeFromJSON(coder.GetScheme().AsJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	coder.SetScheme(scheme)
+	if got := coder.Size(values...); exp != got {
+		t.Errorf("expected size %d, got %d", exp, got)
+	}
+	if data := coder.Encode(values...); len(data) != exp {
+		t.Errorf("expected length %d, got %d", exp, len(data))
+	}
+}
+
+type (
+	binaryT struct{ s string }
+	gobT    struct{ s string }
+)
+
+func (b *binaryT) MarshalBinary() ([]byte, error)    { return []byte(b.s), nil }
+func (b *binaryT) UnmarshalBinary(data []byte) error { b.s = string(data); return nil }
+func (g *gobT) GobEncode() ([]byte, error)           { return []byte(g.s + g.s), nil }
+func (g *gobT) GobDecode(data []byte) error          { g.s = string(data[:len(data)/2]); return nil }
+
+// flagsT packs its bools with bools of enclosing value as gotinygen does
+type flagsT [3]bool
+
+func (f *flagsT) GotinyEncode(buf []byte) []byte {
+	var boolPos int
+	var boolBit byte
+	return f.GotinyEncodeBools(buf, &boolPos, &boolBit)
+}
+
+func (f *flagsT) GotinyDecode(buf []byte) int {
+	var bools, boolBit byte
+	return f.GotinyDecodeBools(buf, &bools, &boolBit)
+}
+
+func (f *flagsT) GotinyEncodeBools(buf []byte, boolPos *int, boolBit *byte) []byte {
+	for _, v := range f {
+		if *boolBit == 0 {
+			*boolPos, *boolBit = len(buf), 1
+			buf = append(buf, 0)
+		}
+		if v {
+			buf[*boolPos] |= *boolBit
+		}
+		*boolBit <<= 1
+	}
+	return buf
+}
+
+func (f *flagsT) GotinyDecodeBools(buf []byte, bools, boolBit *byte) (n int) {
+	for i := range f {
+		if *boolBit == 0 {
+			*bools, *boolBit = buf[n], 1
+			n++
+		}
+		f[i] = *bools&*boolBit != 0
+		*boolBit <<= 1
+	}
+	return n
+}
+
+func TestSizeSerializers(t *testing.T) {
+	g := gotinyTest("gotiny")
+	values := []interface{}{&binaryT{"binary"}, &gobT{"gob"}, &g, &[]binaryT{{}, {"a"}}, &[]flagsT{{true}, {}, {2: true}}}
+	coder := gotiny.NewWithPtr(values...)
+	if exp, got := len(coder.Encode(values...)), coder.Size(values...); exp != got {
+		t.Errorf("expected size %d, got %d", exp, got)
+	}
+}
+
+func TestBoolSerializer(t *testing.T) {
+	type (
+		wrapped struct {
+			A bool
+			F []flagsT
+			B bool
+		}
+		plain struct {
+			A bool
+			F [][3]bool
+			B bool
+		}
+	)
+	src := wrapped{A: true, F: []flagsT{{true}, {1: true}, {2: true}}, B: true}
+	data := gotiny.Marshal(&src)
+	if exp := gotiny.Marshal(&plain{A: true, F: [][3]bool{{true}, {1: true}, {2: true}}, B: true}); string(data) != string(exp) {
+		t.Fatalf("expected bools packed as without methods %v, got %v", exp, data)
+	}
+	if exp, got := len(data), gotiny.NewWithPtr(&src).Size(&src); exp != got {
+		t.Errorf("expected size %d, got %d", exp, got)
+	}
+	var got wrapped
+	if n := gotiny.Unmarshal(data, &got); n != len(data) {
+		t.Fatalf("decoded %d bytes of %d", n, len(data))
+	}
+	Assert(t, data, src, got)
+}
+
+func TestSizeAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations of pooled scratch of maps aren't stable with race detector")
+	}
+	type sizeAllocsT struct {
+		M      map[uint32]float64
+		Packed []float64 `gotiny:"packed"`
+	}
+	v := sizeAllocsT{M: map[uint32]float64{1: 1, 7: 2}, Packed: make([]float64, 100)}
+	coder := gotiny.NewWithPtr(&v)
+	if n := testing.AllocsPerRun(100, func() { coder.Size(&v) }); n != 0 {
+		t.Errorf("expected no allocations, got %v", n)
+	}
+}
@@ -0,0 +1,124 @@
+package gotiny
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+type sizeEng func(*Encoder, unsafe.Pointer) // counts length of encoded value into Encoder.size
+
+var (
+	sizeEngines = [...]sizeEng{
+		reflect.Invalid:       sizeIgnore,
+		reflect.Bool:          sizeBool,
+		reflect.Int:           sizeInt,
+		reflect.Int8:          sizeByte,
+		reflect.Int16:         sizeInt16,
+		reflect.Int32:         sizeInt32,
+		reflect.Int64:         sizeInt64,
+		reflect.Uint:          sizeUint,
+		reflect.Uint8:         sizeByte,
+		reflect.Uint16:        sizeUint16,
+		reflect.Uint32:        sizeUint32,
+		reflect.Uint64:        sizeUint64,
+		reflect.Uintptr:       sizeUintptr,
+		reflect.UnsafePointer: sizeUintptr,
+		reflect.Float32:       sizeFloat32,
+		reflect.Float64:       sizeFloat64,
+		reflect.Complex64:     sizeComplex64,
+		reflect.Complex128:    sizeComplex128,
+		reflect.String:        sizeString,
+	}
+)
+
+// sizeBool counts byte of bools when bool is first in it, like encBool appends it
+func (e *Encoder) sizeBool() {
+	if e.boolBit == 0 {
+		e.size++
+		e.boolBit = 1
+	}
+	e.boolBit <<= 1
+}
+
+func (e *Encoder) sizeLength(l int) { e.size += uint32Size(uint32(l)) }
+func (e *Encoder) sizeBlock(l int)  { e.size += uint32Size(uint32(l)) + l } // length followed by l bytes
+
+// uint64Size returns length of varint written by encUint64
+func uint64Size(v uint64) int {
+	switch {
+	case v < 1<<7-1:
+		return 1
+	case v < 1<<14-1:
+		return 2
+	case v < 1<<21-1:
+		return 3
+	case v < 1<<28-1:
+		return 4
+	case v < 1<<35-1:
+		return 5
+	case v < 1<<42-1:
+		return 6
+	case v < 1<<49-1:
+		return 7
+	case v < 1<<56-1:
+		return 8
+	}
+	return 9
+}
+
+// uint32Size returns length of varint written by encUint32
+func uint32Size(v uint32) int {
+	switch {
+	case v < 1<<7-1:
+		return 1
+	case v < 1<<14-1:
+		return 2
+	case v < 1<<21-1:
+		return 3
+	case v < 1<<28-1:
+		return 4
+	}
+	return 5
+}
+
+// uint16Size returns length of varint written by encUint16
+func uint16Size(v uint16) int {
+	switch {
+	case v < 1<<7-1:
+		return 1
+	case v < 1<<14-1:
+		return 2
+	}
+	return 3
+}
+
+func sizeIgnore(*Encoder, unsafe.Pointer)      {}
+func sizeBool(e *Encoder, _ unsafe.Pointer)    { e.sizeBool() }
+func sizeByte(e *Encoder, _ unsafe.Pointer)    { e.size++ }
+func sizeInt(e *Encoder, p unsafe.Pointer)     { e.size += uint64Size(int64ToUint64(int64(*(*int)(p)))) }
+func sizeInt16(e *Encoder, p unsafe.Pointer)   { e.size += uint16Size(int16ToUint16(*(*int16)(p))) }
+func sizeInt32(e *Encoder, p unsafe.Pointer)   { e.size += uint32Size(int32ToUint32(*(*int32)(p))) }
+func sizeInt64(e *Encoder, p unsafe.Pointer)   { e.size += uint64Size(int64ToUint64(*(*int64)(p))) }
+func sizeUint16(e *Encoder, p unsafe.Pointer)  { e.size += uint16Size(*(*uint16)(p)) }
+func sizeUint32(e *Encoder, p unsafe.Pointer)  { e.size += uint32Size(*(*uint32)(p)) }
+func sizeUint64(e *Encoder, p unsafe.Pointer)  { e.size += uint64Size(*(*uint64)(p)) }
+func sizeUint(e *Encoder, p unsafe.Pointer)    { e.size += uint64Size(uint64(*(*uint)(p))) }
+func sizeUintptr(e *Encoder, p unsafe.Pointer) { e.size += uint64Size(uint64(*(*uintptr)(p))) }
+func sizeFloat32(e *Encoder, p unsafe.Pointer) { e.size += uint32Size(float32ToUint32(p)) }
+func sizeFloat64(e *Encoder, p unsafe.Pointer) { e.size += uint64Size(float64ToUint64(p)) }
+func sizeString(e *Encoder, p unsafe.Pointer)  { e.sizeBlock(len(*(*string)(p))) }
+func sizeTime(e *Encoder, p unsafe.Pointer) {
+	e.size += uint64Size(uint64((*time.Time)(p).UnixNano()))
+}
+func sizeComplex64(e *Encoder, p unsafe.Pointer) { e.size += uint64Size(*(*uint64)(p)) }
+func sizeComplex128(e *Encoder, p unsafe.Pointer) {
+	e.size += uint64Size(*(*uint64)(p)) + uint64Size(*(*uint64)(unsafe.Pointer(uintptr(p) + ptr1Size)))
+}
+
+func sizeBytes(e *Encoder, p unsafe.Pointer) {
+	e.sizeBool()
+	if !isNil(p) {
+		e.sizeBlock(len(*(*[]byte)(p)))
+	}
+}
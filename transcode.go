@@ -0,0 +1,293 @@
+package gotiny
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// transcodeWriter writes values in self-describing binary format like MessagePack or CBOR
+type transcodeWriter interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(i int64)
+	writeUint(u uint64)
+	writeFloat(f float64, bits int)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeTime(t time.Time)
+	writeArray(n int) // header of array of n values
+	writeMap(n int)   // header of map of n pairs of keys and values
+}
+
+// transcodeReader reads values written by transcodeWriter, they are
+// nil, bool, int64, uint64, float64, string, []byte, time.Time, []interface{} or []MapEntry for maps
+type transcodeReader interface {
+	read() interface{}
+	rest() int // number of bytes not read yet
+}
+
+// transcodeTo writes buf encoded with scheme to w, scheme of coder produces array of top level values
+func transcodeTo(w transcodeWriter, scheme *Scheme, buf []byte) (err error) {
+	v, err := DecodeDynamic(scheme, buf)
+	if err != nil {
+		return err
+	}
+	defer recoverDynamic(&err, "gotiny: %v")
+	if scheme.isRoot() {
+		values := v.([]Value)
+		w.writeArray(len(values))
+		for i, child := range scheme.Childs {
+			writeTranscoded(w, child, values[i])
+		}
+	} else {
+		writeTranscoded(w, scheme, v)
+	}
+	return nil
+}
+
+// transcodeFrom encodes value read from r with scheme
+func transcodeFrom(r transcodeReader, scheme *Scheme) (buf []byte, err error) {
+	defer recoverDynamic(&err, "gotiny: corrupted data: %v")
+	v := r.read()
+	if r.rest() > 0 {
+		return nil, errors.New("gotiny: unexpected data after value")
+	}
+	if scheme.isRoot() {
+		values, ok := v.([]interface{})
+		if !ok || len(values) != len(scheme.Childs) {
+			return nil, fmt.Errorf("gotiny: expected array of %d values, got %s", len(scheme.Childs), transcodedKind(v))
+		}
+		for i, child := range scheme.Childs {
+			values[i] = fromTranscoded(child, values[i], "["+strconv.Itoa(i)+"]")
+		}
+		v = values
+	} else {
+		v = fromTranscoded(scheme, v, "")
+	}
+	return EncodeDynamic(scheme, v)
+}
+
+// writeTranscoded writes v decoded by DecodeDynamic, structs are maps keyed by field names,
+// complex numbers are arrays [real, imag], interfaces are maps {"type": name, "value": value}
+// and values with custom encoding are binary with bytes written by gotiny.
+func writeTranscoded(w transcodeWriter, s *Scheme, v Value) {
+	if v == nil {
+		w.writeNil()
+		return
+	}
+	switch s.Type {
+	case typeIgnore:
+		w.writeNil()
+	case typeBool:
+		w.writeBool(v.(bool))
+	case typeInt, typeInt8, typeInt16, typeInt32, typeInt64:
+		w.writeInt(v.(int64))
+	case typeUint, typeUint8, typeUint16, typeUint32, typeUint64:
+		w.writeUint(v.(uint64))
+	case typeFloat32:
+		w.writeFloat(v.(float64), 32)
+	case typeFloat64:
+		w.writeFloat(v.(float64), 64)
+	case typeComplex64, typeComplex128:
+		bits := 64
+		if s.Type == typeComplex64 {
+			bits = 32
+		}
+		c := v.(complex128)
+		w.writeArray(2)
+		w.writeFloat(real(c), bits)
+		w.writeFloat(imag(c), bits)
+	case typeString, typeBytes:
+		if b, ok := v.([]byte); ok {
+			w.writeBytes(b)
+		} else {
+			w.writeString(v.(string))
+		}
+	case typeTime:
+		w.writeTime(v.(time.Time))
+	case typeStruct:
+		fields := v.(map[string]Value)
+		w.writeMap(len(s.Childs))
+		for _, child := range s.Childs {
+			w.writeString(child.Name)
+			writeTranscoded(w, child, fields[child.Name])
+		}
+	case typePointer:
+		writeTranscoded(w, s.child(0), v)
+	case typeArray, typeSlice:
+		elems := v.([]Value)
+		w.writeArray(len(elems))
+		for _, elem := range elems {
+			writeTranscoded(w, s.child(0), elem)
+		}
+	case typeMap:
+		// entries are sorted like by EncodeDynamic, so the same maps have the same bytes
+		entries := v.([]MapEntry)
+		sort.Slice(entries, func(i, j int) bool { return fmt.Sprint(entries[i].Key) < fmt.Sprint(entries[j].Key) })
+		w.writeMap(len(entries))
+		for _, entry := range entries {
+			writeTranscoded(w, s.child(0), entry.Key)
+			writeTranscoded(w, s.child(1), entry.Value)
+		}
+	case typeInterface:
+		iv := v.(InterfaceValue)
+		w.writeMap(2)
+		w.writeString("type")
+		w.writeString(iv.Type)
+		w.writeString("value")
+		impl, _ := s.implScheme(iv.Type)
+		writeTranscoded(w, impl, iv.Value)
+	case typeCustom:
+		e := &Encoder{}
+		e.encTyped(s.customType(), v)
+		w.writeBytes(e.buf)
+	default:
+		panic(dynamicErrorf(s.Name, "unknown scheme type %s", s.Type))
+	}
+}
+
+// fromTranscoded converts v read by transcodeReader into value accepted by EncodeDynamic
+func fromTranscoded(s *Scheme, v interface{}, path string) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch s.Type {
+	case typeComplex64, typeComplex128:
+		parts, ok := v.([]interface{})
+		if !ok || len(parts) != 2 {
+			panic(dynamicErrorf(path, "expected [real, imag], got %s", transcodedKind(v)))
+		}
+		return complex(dynamicFloat(parts[0], path), dynamicFloat(parts[1], path))
+	case typeStruct:
+		entries, ok := v.([]MapEntry)
+		if !ok {
+			panic(dynamicErrorf(path, "expected map, got %s", transcodedKind(v)))
+		}
+		fields := make(map[string]interface{}, len(entries))
+		for _, entry := range entries {
+			name, ok := entry.Key.(string)
+			if !ok {
+				panic(dynamicErrorf(path, "expected field name, got %s", transcodedKind(entry.Key)))
+			}
+			fields[name] = entry.Value
+		}
+		for _, child := range s.Childs {
+			if field, ok := fields[child.Name]; ok {
+				fieldPath := child.Name
+				if path != "" {
+					fieldPath = path + "." + child.Name
+				}
+				fields[child.Name] = fromTranscoded(child, field, fieldPath)
+			}
+		}
+		return fields
+	case typePointer:
+		return fromTranscoded(s.child(0), v, path)
+	case typeArray, typeSlice:
+		elems, ok := v.([]interface{})
+		if !ok {
+			panic(dynamicErrorf(path, "expected array, got %s", transcodedKind(v)))
+		}
+		for i, elem := range elems {
+			elems[i] = fromTranscoded(s.child(0), elem, path+"["+strconv.Itoa(i)+"]")
+		}
+		return elems
+	case typeMap:
+		entries, ok := v.([]MapEntry)
+		if !ok {
+			panic(dynamicErrorf(path, "expected map, got %s", transcodedKind(v)))
+		}
+		for i, entry := range entries {
+			key := fromTranscoded(s.child(0), entry.Key, path+"{key}")
+			entries[i] = MapEntry{Key: key, Value: fromTranscoded(s.child(1), entry.Value, path+"["+fmt.Sprint(key)+"]")}
+		}
+		return entries
+	case typeInterface:
+		entries, _ := v.([]MapEntry)
+		var name string
+		var value interface{}
+		for _, entry := range entries {
+			switch entry.Key {
+			case "type":
+				name, _ = entry.Value.(string)
+			case "value":
+				value = entry.Value
+			}
+		}
+		if name == "" || len(entries) != 2 {
+			panic(dynamicErrorf(path, `expected map {"type": name, "value": value}, got %s`, transcodedKind(v)))
+		}
+		impl, ok := s.implScheme(name)
+		if !ok {
+			panic(dynamicErrorf(path, "type %s is not registered", name))
+		}
+		return InterfaceValue{Type: name, Value: fromTranscoded(impl, value, path)}
+	case typeCustom:
+		b, ok := v.([]byte)
+		if !ok {
+			panic(dynamicErrorf(path, "expected binary, got %s", transcodedKind(v)))
+		}
+		// bytes are decoded with engine of registered type to check them
+		d := &Decoder{buf: b[:len(b):len(b)]}
+		value := d.decDynamic(s)
+		if d.index != len(b) {
+			panic(dynamicErrorf(path, "%d bytes after value with custom encoding", len(b)-d.index))
+		}
+		return value
+	}
+	return v
+}
+
+// transcodedKind returns name of type of v read by transcodeReader
+func transcodedKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case int64, uint64, float64:
+		return "number"
+	case string:
+		return "string"
+	case []byte:
+		return "binary"
+	case time.Time:
+		return "time"
+	case []interface{}:
+		return "array"
+	}
+	return "map"
+}
+
+// readLength checks length n read from data, so corrupted length doesn't allocate too much memory
+func readLength(n uint64, rest int) int {
+	if n > uint64(rest) {
+		panic(dynamicError{fmt.Errorf("gotiny: corrupted data: length %d is greater than %d remaining bytes", n, rest)})
+	}
+	return int(n)
+}
+
+// float16 converts IEEE 754 half precision float to float64
+func float16(h uint16) float64 {
+	exp, frac := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(frac, -24)
+	case 0x1f:
+		f = math.Inf(1)
+		if frac != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(1+frac/1024, exp-15)
+	}
+	if h>>15 != 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,72 @@
+package gotiny_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/niubaoshu/gotiny"
+)
+
+type transcodeT struct {
+	A int8
+	B string
+	C []byte
+	T time.Time
+}
+
+func TestTranscode(t *testing.T) {
+	i := 5
+	src := dynamicT{
+		B: true, I8: -3, I: math.MinInt64, U16: 300, F32: 1.5, C64: complex(1, -2), S: "str", Raw: []byte{1, 2},
+		T: time.Unix(100, 5), Arr: [2]uint8{7, 8}, Sl: []*int{&i, nil}, M: map[string]int32{"a": -1},
+		Any: tint(4), Tree: &compatTree{Value: 1, Children: []*compatTree{{Value: 2}}},
+	}
+	coder := gotiny.New(dynamicT{})
+	data := coder.Encode(&src)
+	for _, c := range []struct {
+		name string
+		to   func(*gotiny.Scheme, []byte) ([]byte, error)
+		from func(*gotiny.Scheme, []byte) ([]byte, error)
+	}{
+		{"msgpack", gotiny.ToMsgpack, gotiny.FromMsgpack},
+		{"cbor", gotiny.ToCBOR, gotiny.FromCBOR},
+	} {
+		transcoded, err := c.to(coder.GetScheme(), data)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		back, err := c.from(coder.GetScheme(), transcoded)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		if !bytes.Equal(data, back) {
+			t.Errorf("%s: expected\n%v\ngot\n%v", c.name, data, back)
+		}
+		if _, err := c.from(coder.GetScheme(), transcoded[:len(transcoded)-1]); err == nil {
+			t.Errorf("%s: expected error for truncated data", c.name)
+		}
+	}
+}
+
+func TestTranscodeFormats(t *testing.T) {
+	coder := gotiny.New(transcodeT{})
+	data := coder.Encode(&transcodeT{A: -3, B: "x", C: []byte{7}, T: time.Unix(1, 0)})
+	msgpack := []byte{0x91, 0x84, 0xa1, 'A', 0xfd, 0xa1, 'B', 0xa1, 'x', 0xa1, 'C', 0xc4, 1, 7, 0xa1, 'T', 0xd6, 0xff, 0, 0, 0, 1}
+	if got, err := gotiny.ToMsgpack(coder.GetScheme(), data); err != nil || !bytes.Equal(msgpack, got) {
+		t.Errorf("msgpack: expected\n%x\ngot\n%x %v", msgpack, got, err)
+	}
+	cbor := append([]byte{0x81, 0xa4, 0x61, 'A', 0x22, 0x61, 'B', 0x61, 'x', 0x61, 'C', 0x41, 7, 0x61, 'T', 0xc0, 0x74},
+		"1970-01-01T00:00:01Z"...)
+	if got, err := gotiny.ToCBOR(coder.GetScheme(), data); err != nil || !bytes.Equal(cbor, got) {
+		t.Errorf("cbor: expected\n%x\ngot\n%x %v", cbor, got, err)
+	}
+
+	// indefinite lengths, epoch time and half float of other encoders
+	other := []byte{0x9f, 0xbf, 0x61, 'A', 0xf9, 0xc2, 0x00, 0x61, 'B', 0x7f, 0x61, 'x', 0xff, 0x61, 'C', 0x5f, 0x41, 7, 0xff,
+		0x61, 'T', 0xc1, 0x01, 0xff, 0xff}
+	if got, err := gotiny.FromCBOR(coder.GetScheme(), other); err != nil || !bytes.Equal(data, got) {
+		t.Errorf("cbor: expected\n%v\ngot\n%v %v", data, got, err)
+	}
+}
@@ -105,8 +105,41 @@ type GoTinySerializer interface {
 	GotinyDecode([]byte) int
 }
 
-func implementOtherSerializer(rt reflect.Type) (encEng encEng, decEng decEng) {
+// GoTinyBoolSerializer is implemented by pointers to types with methods generated by gotinygen,
+// encoder and decoder prefer it to GoTinySerializer. Bools of value share bytes with bools written before it,
+// as they do when value is encoded by reflective engines, so generated methods don't change encoded data.
+type GoTinyBoolSerializer interface {
+	GoTinySerializer
+	// GotinyEncodeBools appends value to buf like GotinyEncode, bools are set in buf[*boolPos] from bit *boolBit
+	// until it is shifted out, position of bits after value is stored back.
+	GotinyEncodeBools(buf []byte, boolPos *int, boolBit *byte) []byte
+	// GotinyDecodeBools decodes value like GotinyDecode, bools are read from byte *bools from bit *boolBit
+	// until it is shifted out, byte of bools and bit after value are stored back.
+	GotinyDecodeBools(buf []byte, bools, boolBit *byte) int
+}
+
+func implementOtherSerializer(rt reflect.Type) (encEng encEng, decEng decEng, sizeEng sizeEng) {
 	rtNil := reflect.Zero(reflect.PtrTo(rt)).Interface()
+	if _, ok := rtNil.(GoTinyBoolSerializer); ok {
+		encEng = func(e *Encoder, p unsafe.Pointer) {
+			e.buf = reflect.NewAt(rt, p).Interface().(GoTinyBoolSerializer).GotinyEncodeBools(e.buf, &e.boolPos, &e.boolBit)
+		}
+		decEng = func(d *Decoder, p unsafe.Pointer) {
+			d.index += reflect.NewAt(rt, p).Interface().(GoTinyBoolSerializer).GotinyDecodeBools(d.buf[d.index:], &d.boolPos, &d.boolBit)
+		}
+		// value is encoded after placeholder of byte of bools being filled, which is already counted
+		sizeEng = func(e *Encoder, p unsafe.Pointer) {
+			start, boolPos := len(e.buf), len(e.buf)
+			if e.boolBit != 0 {
+				e.buf = append(e.buf, 0)
+				e.size--
+			}
+			e.buf = reflect.NewAt(rt, p).Interface().(GoTinyBoolSerializer).GotinyEncodeBools(e.buf, &boolPos, &e.boolBit)
+			e.size += len(e.buf) - start
+			e.buf = e.buf[:start]
+		}
+		return
+	}
 	if _, ok := rtNil.(GoTinySerializer); ok {
 		encEng = func(e *Encoder, p unsafe.Pointer) {
 			e.buf = reflect.NewAt(rt, p).Interface().(GoTinySerializer).GotinyEncode(e.buf)
@@ -114,6 +147,13 @@ func implementOtherSerializer(rt reflect.Type) (encEng encEng, decEng decEng) {
 		decEng = func(d *Decoder, p unsafe.Pointer) {
 			d.index += reflect.NewAt(rt, p).Interface().(GoTinySerializer).GotinyDecode(d.buf[d.index:])
 		}
+		// length is known only after encoding, it's encoded after data in buf and removed
+		sizeEng = func(e *Encoder, p unsafe.Pointer) {
+			start := len(e.buf)
+			e.buf = reflect.NewAt(rt, p).Interface().(GoTinySerializer).GotinyEncode(e.buf)
+			e.size += len(e.buf) - start
+			e.buf = e.buf[:start]
+		}
 		return
 	}
 
@@ -135,6 +175,13 @@ func implementOtherSerializer(rt reflect.Type) (encEng encEng, decEng decEng) {
 				panic(err)
 			}
 		}
+		sizeEng = func(e *Encoder, p unsafe.Pointer) {
+			buf, err := reflect.NewAt(rt, p).Interface().(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				panic(err)
+			}
+			e.sizeBlock(len(buf))
+		}
 		return
 	}
 
@@ -155,12 +202,19 @@ func implementOtherSerializer(rt reflect.Type) (encEng encEng, decEng decEng) {
 				panic(err)
 			}
 		}
+		sizeEng = func(e *Encoder, p unsafe.Pointer) {
+			buf, err := reflect.NewAt(rt, p).Interface().(gob.GobEncoder).GobEncode()
+			if err != nil {
+				panic(err)
+			}
+			e.sizeBlock(len(buf))
+		}
 	}
 	return
 }
 
 // rt.kind is reflect.struct
-func getFieldType(rt reflect.Type, baseOff uintptr) (names []string, fields []reflect.Type, offs []uintptr) {
+func getFieldType(rt reflect.Type, baseOff uintptr) (names []string, fields []reflect.Type, offs []uintptr, packed []bool) {
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
 
@@ -184,6 +238,7 @@ func getFieldType(rt reflect.Type, baseOff uintptr) (names []string, fields []re
 		fields = append(fields, ft)
 		names = append(names, name)
 		offs = append(offs, field.Offset+baseOff)
+		packed = append(packed, packedField(field))
 	}
 	return
 }